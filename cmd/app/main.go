@@ -12,6 +12,7 @@ import (
 	"github.com/dzhordano/maps-api/internal/config"
 	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller"
 	"github.com/dzhordano/maps-api/internal/delivery/http/v1/route"
+	"github.com/dzhordano/maps-api/internal/domain"
 	"github.com/dzhordano/maps-api/internal/httpserver"
 	"github.com/dzhordano/maps-api/internal/repository"
 	"github.com/dzhordano/maps-api/internal/usecase"
@@ -46,14 +47,30 @@ func main() {
 
 	wRepo := repository.NewWaypointRepo(pool)
 	rRepo := repository.NewRoutesRepo(pool)
-
-	rUsecase := usecase.NewRoutesUsecase(rRepo, log)
-	wUsecase := usecase.NewWaypointsUsecase(wRepo, rRepo, log)
-
-	rController := controller.NewRouteController(log, rUsecase)
+	sRepo := repository.NewStationsRepo(pool)
+	vRepo := repository.NewValidationRepo(pool)
+	vtRepo := repository.NewTypesRepo(pool, domain.VehicleTypes)
+	rtRepo := repository.NewTypesRepo(pool, domain.RouteTypes)
+	dRepo := repository.NewDraftsRepo(pool)
+	aRepo := repository.NewAuditRepo(pool)
+
+	vUsecase := usecase.NewValidationUsecase(vRepo, log)
+	dUsecase := usecase.NewDraftsUsecase(dRepo, wRepo, rRepo, aRepo, vUsecase, log)
+	rUsecase := usecase.NewRoutesUsecase(rRepo, wRepo, vtRepo, rtRepo, aRepo, dUsecase, log)
+	wUsecase := usecase.NewWaypointsUsecase(wRepo, rRepo, sRepo, aRepo, dUsecase, log)
+	sUsecase := usecase.NewStationsUsecase(sRepo, wRepo, log)
+	vtUsecase := usecase.NewTypesUsecase(vtRepo, "vehicle type", log)
+	rtUsecase := usecase.NewTypesUsecase(rtRepo, "route type", log)
+
+	rController := controller.NewRouteController(log, rUsecase, cfg.Routes.ReverseRadius)
 	wController := controller.NewWaypointsController(log, wUsecase)
+	sController := controller.NewStationsController(log, sUsecase)
+	vController := controller.NewValidationController(log, vUsecase)
+	vtController := controller.NewTypesController(log, vtUsecase, "vehicle types")
+	rtController := controller.NewTypesController(log, rtUsecase, "route types")
+	dController := controller.NewDraftsController(log, dUsecase)
 
-	route.SetupV1(log, wController, rController, r)
+	route.SetupV1(log, wController, rController, sController, vController, vtController, rtController, dController, r)
 
 	srv := httpserver.New(net.JoinHostPort(cfg.HTTP.Host, cfg.HTTP.Port), r)
 
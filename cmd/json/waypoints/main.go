@@ -7,6 +7,7 @@ import (
 	"net/http"
 
 	reqmodels "github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/requests"
+	respmodels "github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/responses"
 	jsonbus "github.com/dzhordano/maps-api/json"
 )
 
@@ -25,25 +26,38 @@ func main() {
 
 	c := &http.Client{}
 
-	for _, wp := range waypoints {
-		j, err := json.Marshal(wp)
-		if err != nil {
-			panic(err)
-		}
+	j, err := json.Marshal(waypoints)
+	if err != nil {
+		panic(err)
+	}
 
-		req, err := http.NewRequest("POST", "http://localhost:9000/api/v1/waypoints", bytes.NewBuffer(j))
-		if err != nil {
-			panic(err)
-		}
+	req, err := http.NewRequest("POST", "http://localhost:9000/api/v1/waypoints:batch?mode=best_effort", bytes.NewBuffer(j))
+	if err != nil {
+		panic(err)
+	}
 
-		r, err := c.Do(req)
-		if err != nil {
-			panic(err)
-		}
+	r, err := c.Do(req)
+	if err != nil {
+		panic(err)
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != 201 {
+		fmt.Println("error:", r.StatusCode)
+		return
+	}
+
+	var resp respmodels.CreateWaypointsBatchResponse
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		panic(err)
+	}
+
+	fmt.Println("created:", resp.Created, "of", len(waypoints))
 
-		if r.StatusCode != 201 {
-			fmt.Println("error:", r.StatusCode)
-			fmt.Println("wp:", wp)
+	for _, res := range resp.Results {
+		if res.Error != "" {
+			fmt.Println("error:", res.Error)
+			fmt.Println("wp:", waypoints[res.Index])
 		}
 	}
 }
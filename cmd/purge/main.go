@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dzhordano/maps-api/internal/config"
+	"github.com/dzhordano/maps-api/internal/repository"
+	"github.com/dzhordano/maps-api/internal/usecase"
+	"github.com/dzhordano/maps-api/pkg/databases/pg"
+	"github.com/dzhordano/maps-api/pkg/logger"
+)
+
+// Окончательное удаление остановок и маршрутов, удаленных больше days дней назад.
+// Запускается периодически (cron); до очистки удаленное можно восстановить через /restore.
+func main() {
+	days := flag.Int("days", 30, "удалять записи, удаленные больше указанного количества дней назад")
+	flag.Parse()
+
+	if *days < 0 {
+		fmt.Fprintln(os.Stderr, "invalid days:", *days)
+		os.Exit(2)
+	}
+
+	cfg := config.MustNew()
+
+	pool, err := pg.NewClient(context.Background(), cfg.PG.DSN)
+	if err != nil {
+		panic(err)
+	}
+	defer pg.Close(pool)
+
+	log := logger.MustNewSlogLogger(os.Stderr, cfg.LogLevel)
+
+	pUsecase := usecase.NewPurgeUsecase(repository.NewWaypointRepo(pool), repository.NewRoutesRepo(pool), log)
+
+	before := time.Now().AddDate(0, 0, -*days)
+
+	result, err := pUsecase.Purge(context.Background(), before)
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Printf("purged %d waypoints and %d routes deleted before %s\n", result.Waypoints, result.Routes, before.Format(time.RFC3339))
+}
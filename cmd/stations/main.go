@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dzhordano/maps-api/internal/config"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/internal/repository"
+	"github.com/dzhordano/maps-api/internal/usecase"
+	"github.com/dzhordano/maps-api/pkg/databases/pg"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// Кластеризация остановок в станции: печатает предложенные группы похожих остановок рядом друг с другом.
+// С флагом -apply предложения сразу сохраняются как станции.
+func main() {
+	radius := flag.Float64("radius", 150, "максимальное расстояние между остановками станции в метрах")
+	similarity := flag.Float64("similarity", 0.6, "минимальная схожесть названий (0..1)")
+	apply := flag.Bool("apply", false, "создать станции из предложений")
+	flag.Parse()
+
+	cfg := config.MustNew()
+
+	pool, err := pg.NewClient(context.Background(), cfg.PG.DSN)
+	if err != nil {
+		panic(err)
+	}
+	defer pg.Close(pool)
+
+	log := logger.MustNewSlogLogger(os.Stderr, cfg.LogLevel)
+
+	wRepo := repository.NewWaypointRepo(pool)
+	sRepo := repository.NewStationsRepo(pool)
+
+	sUsecase := usecase.NewStationsUsecase(sRepo, wRepo, log)
+
+	ctx := context.Background()
+
+	proposals, err := sUsecase.Propose(ctx, domain.SimilarityParams{
+		Radius:        *radius,
+		MinSimilarity: *similarity,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	for _, proposal := range proposals {
+		fmt.Printf("%s (%d)\n", proposal.Name, len(proposal.Waypoints))
+
+		ids := make([]uuid.UUID, len(proposal.Waypoints))
+		for i, wp := range proposal.Waypoints {
+			ids[i] = wp.ID
+			fmt.Printf("  %s  %s  %f,%f\n", wp.ID, wp.Name, wp.Latitude, wp.Longitude)
+		}
+
+		if !*apply {
+			continue
+		}
+
+		station, err := sUsecase.Create(ctx, domain.Station{Name: proposal.Name}, ids)
+		if err != nil {
+			fmt.Println("  error:", err)
+			continue
+		}
+
+		fmt.Println("  created station:", station.ID)
+	}
+
+	fmt.Println("proposals:", len(proposals))
+}
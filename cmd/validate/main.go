@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/dzhordano/maps-api/internal/config"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/internal/repository"
+	"github.com/dzhordano/maps-api/internal/usecase"
+	"github.com/dzhordano/maps-api/pkg/databases/pg"
+	"github.com/dzhordano/maps-api/pkg/logger"
+)
+
+// Проверка качества данных сети. Завершается с кодом 1, если найдены ошибки.
+func main() {
+	format := flag.String("format", "text", "формат отчета: text или json")
+	maxGap := flag.Float64("max-gap", domain.DefaultMaxStopGap, "максимальное расстояние между соседними остановками в метрах")
+	flag.Parse()
+
+	if *format != "text" && *format != "json" {
+		fmt.Fprintln(os.Stderr, "invalid format:", *format)
+		os.Exit(2)
+	}
+
+	cfg := config.MustNew()
+
+	pool, err := pg.NewClient(context.Background(), cfg.PG.DSN)
+	if err != nil {
+		panic(err)
+	}
+	defer pg.Close(pool)
+
+	log := logger.MustNewSlogLogger(os.Stderr, cfg.LogLevel)
+
+	vUsecase := usecase.NewValidationUsecase(repository.NewValidationRepo(pool), log)
+
+	report, err := vUsecase.Validate(context.Background(), domain.ValidationParams{MaxStopGap: *maxGap})
+	if err != nil {
+		panic(err)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(report)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	if report.Errors > 0 {
+		pg.Close(pool)
+		os.Exit(1)
+	}
+}
@@ -14,6 +14,7 @@ func init() {
 type Config struct {
 	HTTP     HttpConfig
 	PG       PostgresConfig
+	Routes   RoutesConfig
 	LogLevel string `env:"LOG_LEVEL" env-default:"debug"`
 }
 
@@ -26,6 +27,11 @@ type PostgresConfig struct {
 	DSN string `env:"POSTGRES_DSN"`
 }
 
+type RoutesConfig struct {
+	// Радиус поиска остановки на противоположной стороне дороги (в метрах) при построении обратного направления.
+	ReverseRadius float64 `env:"ROUTES_REVERSE_RADIUS" env-default:"150"`
+}
+
 func MustNew() Config {
 	var cfg Config
 
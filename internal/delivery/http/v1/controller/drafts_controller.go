@@ -0,0 +1,306 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"strconv"
+
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/requests"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+type DraftsController struct {
+	Log           logger.Logger
+	DraftsUsecase domain.DraftsUsecase
+}
+
+func NewDraftsController(log logger.Logger, du domain.DraftsUsecase) *DraftsController {
+	return &DraftsController{
+		Log:           log,
+		DraftsUsecase: du,
+	}
+}
+
+// Draft обрабатывает параметр draft (id черновика).
+// GET-запросы выполняются над сетью с примененным черновиком, изменяющие запросы добавляют изменение в черновик.
+// Изменять через черновик можно только пути из stageable ("POST /waypoints"), остальные изменения с draft отклоняются.
+func (dc *DraftsController) Draft(routes chi.Routes, stageable map[string]bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			draft := r.URL.Query().Get("draft")
+			if draft == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, err := uuid.Parse(draft)
+			if err != nil {
+				w.Header().Add("Content-Type", "application/json")
+				httpResponse(w, http.StatusBadRequest, "invalid draft")
+				return
+			}
+
+			if r.Method != http.MethodGet {
+				if !stageable[r.Method+" "+routePattern(routes, r)] {
+					w.Header().Add("Content-Type", "application/json")
+					httpResponse(w, http.StatusBadRequest, "request cannot be staged in a draft")
+					return
+				}
+
+				next.ServeHTTP(w, r.WithContext(domain.WithDraft(r.Context(), id)))
+				return
+			}
+
+			// Ответ собирается в буфер и отправляется после отката транзакции предпросмотра: применение черновика
+			// блокирует измененные строки, и транзакция не должна оставаться открытой, пока клиент читает ответ
+			response := &bufferedResponse{header: w.Header().Clone()}
+
+			err = dc.DraftsUsecase.Preview(r.Context(), id, func(ctx context.Context) error {
+				next.ServeHTTP(response, r.WithContext(ctx))
+
+				return nil
+			})
+			if err != nil {
+				dc.Log.Error("preview draft", "draft:", id, "error:", err)
+
+				w.Header().Add("Content-Type", "application/json")
+				httpResponse(w, DomainErrorToHTTP(err), err.Error())
+				return
+			}
+
+			response.writeTo(w)
+		})
+	}
+}
+
+// bufferedResponse накапливает ответ обработчика в памяти.
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.WriteHeader(http.StatusOK)
+
+	return b.body.Write(p)
+}
+
+// writeTo отправляет накопленный ответ клиенту.
+func (b *bufferedResponse) writeTo(w http.ResponseWriter) {
+	maps.Copy(w.Header(), b.header)
+
+	b.WriteHeader(http.StatusOK)
+	w.WriteHeader(b.status)
+
+	_, _ = b.body.WriteTo(w)
+}
+
+// routePattern возвращает шаблон пути запроса ("/waypoints/{id}"). Middleware выполняется до выбора обработчика,
+// поэтому шаблон ищется по дереву маршрутов.
+func routePattern(routes chi.Routes, r *http.Request) string {
+	path := r.URL.Path
+	if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePath != "" {
+		path = rctx.RoutePath
+	}
+
+	return routes.Find(chi.NewRouteContext(), r.Method, path)
+}
+
+func (dc *DraftsController) List(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	status := r.URL.Query().Get("status")
+	if status != "" && !domain.ValidDraftStatus(status) {
+		httpResponse(w, http.StatusBadRequest, "invalid status parameter")
+		return
+	}
+
+	drafts, err := dc.DraftsUsecase.List(r.Context(), status)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	dc.Log.Debug("list drafts", "drafts:", len(drafts))
+
+	err = json.NewEncoder(w).Encode(drafts)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (dc *DraftsController) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	dc.Log.Debug("get draft", "parsed id:", id)
+
+	draft, err := dc.DraftsUsecase.GetById(r.Context(), id)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(draft)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (dc *DraftsController) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var draft requests.CreateDraftRequest
+
+	err := json.NewDecoder(r.Body).Decode(&draft)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := draft.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	dc.Log.Debug("create draft", "decoded draft:", draft)
+
+	created, err := dc.DraftsUsecase.Create(r.Context(), draft.Name)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	_ = json.NewEncoder(w).Encode(created)
+}
+
+// Validate проверяет сеть с примененным черновиком (те же проверки, что и /admin/validate).
+func (dc *DraftsController) Validate(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	params, err := parseValidationParams(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := dc.DraftsUsecase.Validate(r.Context(), id, params)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	dc.Log.Debug("validate draft", "draft:", id, "errors:", report.Errors, "warnings:", report.Warns)
+
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// RemoveChange удаляет изменение из открытого черновика и возвращает черновик.
+func (dc *DraftsController) RemoveChange(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	seq, err := strconv.Atoi(chi.URLParam(r, "seq"))
+	if err != nil || seq < 1 {
+		httpResponse(w, http.StatusBadRequest, "invalid seq")
+		return
+	}
+
+	draft, err := dc.DraftsUsecase.RemoveChange(r.Context(), id, seq)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	dc.Log.Debug("remove draft change", "draft:", id, "seq:", seq)
+
+	err = json.NewEncoder(w).Encode(draft)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (dc *DraftsController) Publish(w http.ResponseWriter, r *http.Request) {
+	dc.close(w, r, dc.DraftsUsecase.Publish)
+}
+
+func (dc *DraftsController) Discard(w http.ResponseWriter, r *http.Request) {
+	dc.close(w, r, dc.DraftsUsecase.Discard)
+}
+
+// close публикует или отменяет черновик и возвращает его.
+func (dc *DraftsController) close(w http.ResponseWriter, r *http.Request, fn func(ctx context.Context, id uuid.UUID) (domain.Draft, error)) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	draft, err := fn(r.Context(), id)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	dc.Log.Debug("close draft", "draft:", id, "status:", draft.Status)
+
+	err = json.NewEncoder(w).Encode(draft)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -1,8 +1,11 @@
 package mapper
 
 import (
+	"time"
+
 	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/requests"
 	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
 )
 
 func CreateWaypointRequestToDomain(waypoint requests.CreateWaypointRequest) domain.Waypoint {
@@ -10,6 +13,16 @@ func CreateWaypointRequestToDomain(waypoint requests.CreateWaypointRequest) doma
 		Name:      waypoint.Name,
 		Latitude:  waypoint.Latitude,
 		Longitude: waypoint.Longitude,
+
+		WheelchairBoarding: wheelchairBoardingOrUnknown(waypoint.WheelchairBoarding),
+		Shelter:            waypoint.Shelter,
+		Bench:              waypoint.Bench,
+		Lighting:           waypoint.Lighting,
+		TactilePaving:      waypoint.TactilePaving,
+		PlatformCode:       waypoint.PlatformCode,
+		Description:        waypoint.Description,
+
+		Validity: ValidityRequestToDomain(waypoint.Validity),
 	}
 }
 
@@ -18,6 +31,16 @@ func UpdateWaypointRequestToDomain(waypoint requests.UpdateWaypointRequest) doma
 		Name:      waypoint.Name,
 		Latitude:  waypoint.Latitude,
 		Longitude: waypoint.Longitude,
+
+		WheelchairBoarding: wheelchairBoardingOrUnknown(waypoint.WheelchairBoarding),
+		Shelter:            waypoint.Shelter,
+		Bench:              waypoint.Bench,
+		Lighting:           waypoint.Lighting,
+		TactilePaving:      waypoint.TactilePaving,
+		PlatformCode:       waypoint.PlatformCode,
+		Description:        waypoint.Description,
+
+		Validity: ValidityRequestToDomain(waypoint.Validity),
 	}
 }
 
@@ -29,5 +52,148 @@ func CreateRouteRequestToDomain(route requests.CreateRouteRequest) domain.Route
 		Price:       route.Price,
 		VehicleType: route.VehicleType,
 		RouteType:   route.RouteType,
+		IsLoop:      route.IsLoop,
+
+		ShortName:   route.ShortName,
+		LongName:    route.LongName,
+		Color:       route.Color,
+		TextColor:   route.TextColor,
+		Description: route.Description,
+		URL:         route.URL,
+
+		Validity: ValidityRequestToDomain(route.Validity),
+	}
+}
+
+func UpdateRouteRequestToDomain(route requests.UpdateRouteRequest) domain.RouteUpdate {
+	return domain.RouteUpdate{
+		Name:        route.Name,
+		Price:       route.Price,
+		VehicleType: route.VehicleType,
+		RouteType:   route.RouteType,
+		IsLoop:      route.IsLoop,
+
+		ShortName:   route.ShortName,
+		LongName:    route.LongName,
+		Color:       route.Color,
+		TextColor:   route.TextColor,
+		Description: route.Description,
+		URL:         route.URL,
+
+		ValidFrom: optionalDate(route.ValidFrom),
+		ValidTo:   optionalDate(route.ValidTo),
+	}
+}
+
+func AttachRouteRequestToDomain(waypointId uuid.UUID, route requests.AttachRouteRequest) domain.WaypointRoute {
+	var patternId uuid.UUID
+	if route.PatternID != "" {
+		patternId = uuid.MustParse(route.PatternID)
+	}
+
+	return domain.WaypointRoute{
+		RouteID:     uuid.MustParse(route.RouteID),
+		PatternID:   patternId,
+		WaypointID:  waypointId,
+		RouteKind:   route.RouteKind,
+		RouteNumber: route.RouteNumber,
+		PickupType:  stopRuleOrRegular(route.PickupType),
+		DropOffType: stopRuleOrRegular(route.DropOffType),
+		Validity:    ValidityRequestToDomain(route.Validity),
+	}
+}
+
+// StopRulesRequestToDomain возвращает правила посадки и высадки.
+func StopRulesRequestToDomain(rules requests.StopRulesRequest) (string, string) {
+	return stopRuleOrRegular(rules.PickupType), stopRuleOrRegular(rules.DropOffType)
+}
+
+func CreatePatternRequestToDomain(routeId uuid.UUID, pattern requests.CreatePatternRequest) (domain.RoutePattern, []uuid.UUID) {
+	waypointIds := make([]uuid.UUID, len(pattern.Waypoints))
+	for i, waypoint := range pattern.Waypoints {
+		waypointIds[i] = uuid.MustParse(waypoint)
+	}
+
+	return domain.RoutePattern{
+		RouteID:   routeId,
+		Name:      pattern.Name,
+		IsDefault: pattern.IsDefault,
+	}, waypointIds
+}
+
+func UpdatePatternRequestToDomain(pattern requests.UpdatePatternRequest) domain.RoutePatternUpdate {
+	return domain.RoutePatternUpdate{
+		Name:      pattern.Name,
+		IsDefault: pattern.IsDefault,
+	}
+}
+
+func wheelchairBoardingOrUnknown(wb string) string {
+	if wb == "" {
+		return domain.WheelchairBoardingUnknown
+	}
+
+	return wb
+}
+
+func ValidityRequestToDomain(validity requests.Validity) domain.Validity {
+	return domain.Validity{
+		ValidFrom: parseDate(validity.ValidFrom),
+		ValidTo:   parseDate(validity.ValidTo),
+	}
+}
+
+// parseDate ожидает уже проверенную дату. Пустая дата - nil (без ограничения).
+func parseDate(date string) *time.Time {
+	if date == "" {
+		return nil
+	}
+
+	parsed, _ := time.Parse(domain.DateLayout, date)
+
+	return &parsed
+}
+
+// optionalDate возвращает nil, если дата не передана, и нулевое время для пустой даты.
+func optionalDate(date *string) *time.Time {
+	if date == nil {
+		return nil
+	}
+
+	if parsed := parseDate(*date); parsed != nil {
+		return parsed
+	}
+
+	return &time.Time{}
+}
+
+func stopRuleOrRegular(rule string) string {
+	if rule == "" {
+		return domain.StopRuleRegular
+	}
+
+	return rule
+}
+
+// WaypointsWithinRequestToDomain ожидает уже проверенный запрос.
+func WaypointsWithinRequestToDomain(within requests.WaypointsWithinRequest) domain.Area {
+	polygons, _ := within.Polygons()
+
+	area := make(domain.Area, 0, len(polygons))
+	for _, polygon := range polygons {
+		area = append(area, domain.Polygon(polygon))
+	}
+
+	return area
+}
+
+func TypeEntryRequestToDomain(code string, entry requests.UpdateTypeEntryRequest) domain.TypeEntry {
+	return domain.TypeEntry{
+		Code:          code,
+		DisplayName:   entry.DisplayName,
+		IconKey:       entry.IconKey,
+		Color:         entry.Color,
+		DefaultSpeed:  entry.DefaultSpeed,
+		GTFSRouteType: entry.GTFSRouteType,
 	}
 }
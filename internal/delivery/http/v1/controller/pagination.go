@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+// Наибольший размер страницы. Больший limit уменьшается до него.
+const maxLimitValue = 1000
+
+// parsePage разбирает параметры пагинации limit, offset и cursor.
+// offset оставлен для обратной совместимости и не сочетается с cursor.
+func parsePage(r *http.Request) (domain.Page, error) {
+	page := domain.Page{
+		Limit:  defaultLimitValue,
+		Offset: defaultOffsetValue,
+	}
+
+	query := r.URL.Query()
+
+	if limit := query.Get("limit"); limit != "" {
+		limitInt, err := parseUint64(limit)
+		if err != nil {
+			return domain.Page{}, errors.New("invalid limit param")
+		}
+		page.Limit = min(limitInt, maxLimitValue)
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		offsetInt, err := parseUint64(offset)
+		if err != nil {
+			return domain.Page{}, errors.New("invalid offset param")
+		}
+		page.Offset = offsetInt
+	}
+
+	page.Cursor = query.Get("cursor")
+	if page.Cursor != "" && page.Offset != 0 {
+		return domain.Page{}, errors.New("cursor and offset params are mutually exclusive")
+	}
+
+	return page, nil
+}
+
+// setPageHeaders выставляет X-Total-Count и, если есть следующая страница, X-Next-Cursor и Link с rel="next".
+// Ссылка повторяет исходный запрос, заменяя offset на cursor. Списки остаются массивами в теле,
+// поэтому курсор передается заголовком (у журнала изменений он есть и в теле).
+func setPageHeaders(w http.ResponseWriter, r *http.Request, nextCursor string, total int) {
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	if nextCursor == "" {
+		return
+	}
+
+	w.Header().Set("X-Next-Cursor", nextCursor)
+
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", nextCursor)
+
+	w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+}
@@ -8,8 +8,11 @@ import (
 
 type AttachRouteRequest struct {
 	RouteID     string `json:"route_id"`
+	PatternID   string `json:"pattern_id"` // Пустой - основной вариант маршрута
 	RouteKind   int    `json:"route_kind"`
 	RouteNumber int    `json:"route_number"`
+	StopRulesRequest
+	Validity
 }
 
 func (r *AttachRouteRequest) Validate() error {
@@ -17,9 +20,23 @@ func (r *AttachRouteRequest) Validate() error {
 		return errors.New("invalid route id")
 	}
 
+	if r.PatternID != "" {
+		if _, err := uuid.Parse(r.PatternID); err != nil {
+			return errors.New("invalid pattern id")
+		}
+	}
+
+	if r.RouteKind < 0 || r.RouteKind > 2 {
+		return errors.New("invalid route kind")
+	}
+
 	if r.RouteNumber < 0 {
 		return errors.New("invalid route number")
 	}
 
-	return nil
+	if err := r.StopRulesRequest.Validate(); err != nil {
+		return err
+	}
+
+	return r.Validity.Validate()
 }
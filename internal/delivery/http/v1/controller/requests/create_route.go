@@ -1,10 +1,6 @@
 package requests
 
-import (
-	"errors"
-
-	"github.com/dzhordano/maps-api/internal/domain"
-)
+import "errors"
 
 type CreateRouteRequest struct {
 	Name        string   `json:"name"`
@@ -13,7 +9,11 @@ type CreateRouteRequest struct {
 	Price       int      `json:"price"`
 	VehicleType string   `json:"vehicle_type"`
 	RouteType   string   `json:"route_type"`
+	IsLoop      bool     `json:"is_loop"`
 	Waypoints   []string `json:"waypoints"`
+
+	RouteBranding
+	Validity
 }
 
 func (r CreateRouteRequest) Validate() error {
@@ -33,11 +33,11 @@ func (r CreateRouteRequest) Validate() error {
 		return errors.New("invalid price")
 	}
 
-	if r.VehicleType == "" || len(r.VehicleType) > 256 || !domain.ValidVehicleType(r.VehicleType) {
+	if r.VehicleType == "" || len(r.VehicleType) > 256 {
 		return errors.New("invalid vehicle type")
 	}
 
-	if r.RouteType == "" || len(r.RouteType) > 256 || !domain.ValidRouteType(r.RouteType) {
+	if r.RouteType == "" || len(r.RouteType) > 256 {
 		return errors.New("invalid route type")
 	}
 
@@ -45,5 +45,9 @@ func (r CreateRouteRequest) Validate() error {
 		return errors.New("invalid waypoints")
 	}
 
-	return nil
+	if err := r.RouteBranding.Validate(); err != nil {
+		return err
+	}
+
+	return r.Validity.Validate()
 }
@@ -0,0 +1,15 @@
+package requests
+
+import "errors"
+
+type CreateDraftRequest struct {
+	Name string `json:"name"`
+}
+
+func (r CreateDraftRequest) Validate() error {
+	if r.Name == "" || len(r.Name) > 256 {
+		return errors.New("invalid name")
+	}
+
+	return nil
+}
@@ -0,0 +1,5 @@
+package requests
+
+type MergeWaypointRequest struct {
+	WaypointID string `json:"waypoint_id"` // Дубликат, который будет объединен с точкой из пути запроса
+}
@@ -0,0 +1,15 @@
+package requests
+
+import "errors"
+
+type MoveRouteRequest struct {
+	RouteNumber int `json:"route_number"`
+}
+
+func (r MoveRouteRequest) Validate() error {
+	if r.RouteNumber < 1 {
+		return errors.New("invalid route number")
+	}
+
+	return nil
+}
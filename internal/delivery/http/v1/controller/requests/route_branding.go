@@ -0,0 +1,52 @@
+package requests
+
+import (
+	"errors"
+	"net/url"
+)
+
+// Оформление маршрута. Пустые названия заменяются названиями по умолчанию.
+type RouteBranding struct {
+	ShortName   string `json:"short_name"`
+	LongName    string `json:"long_name"`
+	Color       string `json:"color"`
+	TextColor   string `json:"text_color"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+}
+
+func (r RouteBranding) Validate() error {
+	return validateRouteBranding(&r.ShortName, &r.LongName, &r.Color, &r.TextColor, &r.Description, &r.URL)
+}
+
+// validateRouteBranding проверяет поля оформления маршрута. nil поля не проверяются.
+func validateRouteBranding(shortName, longName, color, textColor, description, link *string) error {
+	if shortName != nil && len(*shortName) > 32 {
+		return errors.New("invalid short name")
+	}
+
+	if longName != nil && len(*longName) > 255 {
+		return errors.New("invalid long name")
+	}
+
+	if color != nil && *color != "" && !colorRe.MatchString(*color) {
+		return errors.New("invalid color")
+	}
+
+	if textColor != nil && *textColor != "" && !colorRe.MatchString(*textColor) {
+		return errors.New("invalid text color")
+	}
+
+	if description != nil && len(*description) > 4096 {
+		return errors.New("invalid description")
+	}
+
+	if link != nil && *link != "" {
+		parsed, err := url.ParseRequestURI(*link)
+		if err != nil || len(*link) > 2048 || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return errors.New("invalid url")
+		}
+	}
+
+	return nil
+}
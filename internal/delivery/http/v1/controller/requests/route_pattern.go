@@ -0,0 +1,55 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type CreatePatternRequest struct {
+	Name      string   `json:"name"`
+	Waypoints []string `json:"waypoints"`
+	IsDefault bool     `json:"default"` // Сделать вариант основным вместо текущего
+}
+
+func (r CreatePatternRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" || len(r.Name) > 64 {
+		return errors.New("invalid name")
+	}
+
+	if len(r.Waypoints) < 2 {
+		return errors.New("pattern must have at least 2 waypoints")
+	}
+
+	for _, waypoint := range r.Waypoints {
+		if _, err := uuid.Parse(waypoint); err != nil {
+			return errors.New("invalid waypoint id: " + waypoint)
+		}
+	}
+
+	return nil
+}
+
+type UpdatePatternRequest struct {
+	Name      *string `json:"name"`
+	IsDefault *bool   `json:"default"`
+}
+
+func (r UpdatePatternRequest) Validate() error {
+	if r.Name == nil && r.IsDefault == nil {
+		return errors.New("nothing to update")
+	}
+
+	if r.Name != nil {
+		if strings.TrimSpace(*r.Name) == "" || len(*r.Name) > 64 {
+			return errors.New("invalid name")
+		}
+	}
+
+	if r.IsDefault != nil && !*r.IsDefault {
+		return errors.New("default pattern can only be changed by making another pattern default")
+	}
+
+	return nil
+}
@@ -0,0 +1,18 @@
+package requests
+
+import (
+	"errors"
+	"strings"
+)
+
+type SetTranslationRequest struct {
+	Name string `json:"name"`
+}
+
+func (r SetTranslationRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" || len(r.Name) > 255 {
+		return errors.New("invalid name")
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package requests
+
+import "errors"
+
+type CreateStationRequest struct {
+	Name      string   `json:"name"`
+	Waypoints []string `json:"waypoints"`
+}
+
+func (r CreateStationRequest) Validate() error {
+	if r.Name == "" || len(r.Name) > 256 {
+		return errors.New("invalid name")
+	}
+
+	return nil
+}
+
+type UpdateStationRequest struct {
+	Name string `json:"name"`
+}
+
+func (r UpdateStationRequest) Validate() error {
+	if r.Name == "" || len(r.Name) > 256 {
+		return errors.New("invalid name")
+	}
+
+	return nil
+}
+
+type AddStationMemberRequest struct {
+	WaypointID string `json:"waypoint_id"`
+}
@@ -0,0 +1,25 @@
+package requests
+
+import (
+	"errors"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+// Пустое значение - обычная посадка/высадка.
+type StopRulesRequest struct {
+	PickupType  string `json:"pickup_type"`
+	DropOffType string `json:"drop_off_type"`
+}
+
+func (r StopRulesRequest) Validate() error {
+	if r.PickupType != "" && !domain.ValidStopRule(r.PickupType) {
+		return errors.New("invalid pickup type")
+	}
+
+	if r.DropOffType != "" && !domain.ValidStopRule(r.DropOffType) {
+		return errors.New("invalid drop off type")
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package requests
+
+import (
+	"errors"
+	"regexp"
+)
+
+var (
+	typeCodeRe = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+	colorRe    = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+)
+
+type CreateTypeEntryRequest struct {
+	Code string `json:"code"`
+	UpdateTypeEntryRequest
+}
+
+func (r CreateTypeEntryRequest) Validate() error {
+	if !typeCodeRe.MatchString(r.Code) {
+		return errors.New("invalid code")
+	}
+
+	return r.UpdateTypeEntryRequest.Validate()
+}
+
+type UpdateTypeEntryRequest struct {
+	DisplayName   string  `json:"display_name"`
+	IconKey       string  `json:"icon_key"`
+	Color         string  `json:"color"`
+	DefaultSpeed  float64 `json:"default_speed"`
+	GTFSRouteType *int    `json:"gtfs_route_type"`
+}
+
+func (r UpdateTypeEntryRequest) Validate() error {
+	if r.DisplayName == "" || len(r.DisplayName) > 256 {
+		return errors.New("invalid display name")
+	}
+
+	if len(r.IconKey) > 64 {
+		return errors.New("invalid icon key")
+	}
+
+	if r.Color != "" && !colorRe.MatchString(r.Color) {
+		return errors.New("invalid color")
+	}
+
+	if r.DefaultSpeed < 0 || r.DefaultSpeed > 500 {
+		return errors.New("invalid default speed")
+	}
+
+	if r.GTFSRouteType != nil && (*r.GTFSRouteType < 0 || *r.GTFSRouteType > 1702) {
+		return errors.New("invalid gtfs route type")
+	}
+
+	return nil
+}
@@ -3,8 +3,6 @@ package requests
 import (
 	"errors"
 	"strings"
-
-	"github.com/dzhordano/maps-api/internal/domain"
 )
 
 type UpdateRouteRequest struct {
@@ -12,9 +10,26 @@ type UpdateRouteRequest struct {
 	Price       *int    `json:"price"`
 	VehicleType *string `json:"vehicle_type"`
 	RouteType   *string `json:"route_type"`
+	IsLoop      *bool   `json:"is_loop"`
+
+	ShortName   *string `json:"short_name"`
+	LongName    *string `json:"long_name"`
+	Color       *string `json:"color"`
+	TextColor   *string `json:"text_color"`
+	Description *string `json:"description"`
+	URL         *string `json:"url"`
+
+	ValidFrom *string `json:"valid_from"` // Пустая строка - без ограничения
+	ValidTo   *string `json:"valid_to"`   // Пустая строка - без ограничения
 }
 
 func (r UpdateRouteRequest) Validate() error {
+	if r.Name == nil && r.Price == nil && r.VehicleType == nil && r.RouteType == nil && r.IsLoop == nil &&
+		r.ShortName == nil && r.LongName == nil && r.Color == nil && r.TextColor == nil && r.Description == nil && r.URL == nil &&
+		r.ValidFrom == nil && r.ValidTo == nil {
+		return errors.New("nothing to update")
+	}
+
 	if r.Name != nil {
 		if strings.TrimSpace(*r.Name) == "" || len(*r.Name) > 256 {
 			return errors.New("invalid name")
@@ -28,17 +43,20 @@ func (r UpdateRouteRequest) Validate() error {
 	}
 
 	if r.VehicleType != nil {
-		if strings.TrimSpace(*r.VehicleType) == "" || len(*r.VehicleType) > 256 || !domain.ValidVehicleType(*r.VehicleType) {
+		if strings.TrimSpace(*r.VehicleType) == "" || len(*r.VehicleType) > 256 {
 			return errors.New("invalid vehicle type")
 		}
 	}
 
 	if r.RouteType != nil {
-		if strings.TrimSpace(*r.RouteType) == "" || len(*r.RouteType) > 256 || !domain.ValidRouteType(*r.RouteType) {
+		if strings.TrimSpace(*r.RouteType) == "" || len(*r.RouteType) > 256 {
 			return errors.New("invalid route type")
 		}
 	}
 
-	return nil
+	if err := validateRouteBranding(r.ShortName, r.LongName, r.Color, r.TextColor, r.Description, r.URL); err != nil {
+		return err
+	}
 
+	return validateValidity(r.ValidFrom, r.ValidTo)
 }
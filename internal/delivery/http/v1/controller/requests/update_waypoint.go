@@ -6,6 +6,16 @@ type UpdateWaypointRequest struct {
 	Name      string  `json:"name"`
 	Latitude  float64 `json:"lat"`
 	Longitude float64 `json:"lon"`
+
+	WheelchairBoarding string `json:"wheelchair_boarding"`
+	Shelter            *bool  `json:"shelter"`
+	Bench              *bool  `json:"bench"`
+	Lighting           *bool  `json:"lighting"`
+	TactilePaving      *bool  `json:"tactile_paving"`
+	PlatformCode       string `json:"platform_code"`
+	Description        string `json:"description"`
+
+	Validity
 }
 
 func (r UpdateWaypointRequest) Validate() error {
@@ -21,5 +31,9 @@ func (r UpdateWaypointRequest) Validate() error {
 		return errors.New("invalid lon")
 	}
 
-	return nil
+	if err := validateWaypointDetails(r.WheelchairBoarding, r.PlatformCode, r.Description); err != nil {
+		return err
+	}
+
+	return r.Validity.Validate()
 }
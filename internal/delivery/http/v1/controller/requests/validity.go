@@ -0,0 +1,45 @@
+package requests
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+// Период действия в формате YYYY-MM-DD. Пустая дата - без ограничения.
+type Validity struct {
+	ValidFrom string `json:"valid_from"`
+	ValidTo   string `json:"valid_to"`
+}
+
+func (r Validity) Validate() error {
+	return validateValidity(&r.ValidFrom, &r.ValidTo)
+}
+
+// validateValidity проверяет даты начала и окончания действия. nil и пустые даты не проверяются.
+func validateValidity(validFrom, validTo *string) error {
+	var from, to time.Time
+
+	if validFrom != nil && *validFrom != "" {
+		parsed, err := time.Parse(domain.DateLayout, *validFrom)
+		if err != nil {
+			return errors.New("invalid valid from")
+		}
+		from = parsed
+	}
+
+	if validTo != nil && *validTo != "" {
+		parsed, err := time.Parse(domain.DateLayout, *validTo)
+		if err != nil {
+			return errors.New("invalid valid to")
+		}
+		to = parsed
+	}
+
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return errors.New("valid to is before valid from")
+	}
+
+	return nil
+}
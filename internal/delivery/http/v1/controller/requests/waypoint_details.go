@@ -0,0 +1,23 @@
+package requests
+
+import (
+	"errors"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+func validateWaypointDetails(wheelchairBoarding, platformCode, description string) error {
+	if wheelchairBoarding != "" && !domain.ValidWheelchairBoarding(wheelchairBoarding) {
+		return errors.New("invalid wheelchair boarding")
+	}
+
+	if len(platformCode) > 32 {
+		return errors.New("invalid platform code")
+	}
+
+	if len(description) > 1024 {
+		return errors.New("invalid description")
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package requests
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Максимальное количество вершин во всех кольцах области.
+const maxWithinPositions = 10000
+
+// WaypointsWithinRequest - геометрия GeoJSON типа Polygon или MultiPolygon.
+type WaypointsWithinRequest struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+func (r WaypointsWithinRequest) Validate() error {
+	polygons, err := r.Polygons()
+	if err != nil {
+		return err
+	}
+
+	if len(polygons) == 0 {
+		return errors.New("invalid coordinates")
+	}
+
+	positions := 0
+	for _, polygon := range polygons {
+		if len(polygon) == 0 {
+			return errors.New("invalid polygon")
+		}
+
+		for _, ring := range polygon {
+			// Кольцо замкнуто и содержит не меньше 4 точек (треугольник + повтор первой).
+			if len(ring) < 4 || ring[0] != ring[len(ring)-1] {
+				return errors.New("invalid polygon ring")
+			}
+
+			for _, position := range ring {
+				if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+					return errors.New("invalid coordinates")
+				}
+			}
+
+			positions += len(ring)
+		}
+	}
+
+	if positions > maxWithinPositions {
+		return errors.New("polygon is too large")
+	}
+
+	return nil
+}
+
+// Polygons возвращает координаты в виде списка полигонов независимо от типа геометрии.
+func (r WaypointsWithinRequest) Polygons() ([][][][2]float64, error) {
+	switch r.Type {
+	case "Polygon":
+		var polygon [][][2]float64
+		if err := json.Unmarshal(r.Coordinates, &polygon); err != nil {
+			return nil, errors.New("invalid coordinates")
+		}
+
+		return [][][][2]float64{polygon}, nil
+	case "MultiPolygon":
+		var polygons [][][][2]float64
+		if err := json.Unmarshal(r.Coordinates, &polygons); err != nil {
+			return nil, errors.New("invalid coordinates")
+		}
+
+		return polygons, nil
+	default:
+		return nil, errors.New("invalid geometry type")
+	}
+}
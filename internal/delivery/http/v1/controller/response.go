@@ -25,6 +25,8 @@ func DomainErrorToHTTP(err error) int {
 		return http.StatusConflict
 	case domain.ErrBadRequest:
 		return http.StatusBadRequest
+	case domain.ErrUnavailable:
+		return http.StatusServiceUnavailable
 	default:
 		return http.StatusInternalServerError
 	}
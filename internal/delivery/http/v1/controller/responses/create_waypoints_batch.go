@@ -0,0 +1,13 @@
+package responses
+
+type CreateWaypointsBatchItem struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+type CreateWaypointsBatchResponse struct {
+	Mode    string                     `json:"mode"`
+	Created int                        `json:"created"`
+	Results []CreateWaypointsBatchItem `json:"results"`
+}
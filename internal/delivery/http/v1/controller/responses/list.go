@@ -0,0 +1,11 @@
+package responses
+
+import (
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+type HistoryResponse struct {
+	Items      []domain.AuditRecord `json:"items"`
+	NextCursor string               `json:"next_cursor,omitempty"` // Пусто на последней странице
+	Total      int                  `json:"total"`
+}
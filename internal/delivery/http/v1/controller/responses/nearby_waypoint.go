@@ -0,0 +1,12 @@
+package responses
+
+import (
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+type NearbyWaypoint struct {
+	domain.Waypoint
+	DistanceM float64        `json:"distance_m"` // Расстояние от заданных координат в метрах
+	Bearing   float64        `json:"bearing"`    // Азимут в градусах (0 - север, по часовой стрелке)
+	Routes    []domain.Route `json:"routes"`     // Маршруты через остановку, подходящие под фильтры
+}
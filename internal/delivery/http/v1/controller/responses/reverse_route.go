@@ -0,0 +1,11 @@
+package responses
+
+import (
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+type ReverseRouteResponse struct {
+	Route     domain.Route      `json:"route"`
+	Waypoints []domain.Waypoint `json:"waypoints"`
+	Unmatched []domain.Waypoint `json:"unmatched"` // Остановки без пары напротив, требуют ручной проверки
+}
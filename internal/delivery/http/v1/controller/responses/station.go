@@ -0,0 +1,10 @@
+package responses
+
+import (
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+type GetStationByIdResponse struct {
+	Station   domain.Station    `json:"station"`
+	Waypoints []domain.Waypoint `json:"waypoints"`
+}
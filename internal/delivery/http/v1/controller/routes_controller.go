@@ -2,6 +2,7 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,6 +11,7 @@ import (
 	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/requests"
 	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/responses"
 	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/i18n"
 	"github.com/dzhordano/maps-api/pkg/logger"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -18,60 +20,49 @@ import (
 const (
 	defaultLimitValue  = 10
 	defaultOffsetValue = 0
+
+	defaultNearRadiusValue = 500
 )
 
 type RoutesController struct {
-	Log          logger.Logger
-	RouteUsecase domain.RoutesUsecase
+	Log           logger.Logger
+	RouteUsecase  domain.RoutesUsecase
+	ReverseRadius float64 // Радиус поиска остановок напротив по умолчанию (в метрах)
 }
 
-func NewRouteController(log logger.Logger, routeUsecase domain.RoutesUsecase) *RoutesController {
+func NewRouteController(log logger.Logger, routeUsecase domain.RoutesUsecase, reverseRadius float64) *RoutesController {
 	return &RoutesController{
-		Log:          log,
-		RouteUsecase: routeUsecase,
+		Log:           log,
+		RouteUsecase:  routeUsecase,
+		ReverseRadius: reverseRadius,
 	}
 }
 
 func (rc *RoutesController) ListRoutes(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
-	limit := r.URL.Query().Get("limit")
-
-	var limitInt uint64
-	var err error
-
-	if limit == "" {
-		limitInt = defaultLimitValue
-	} else {
-		limitInt, err = parseUint64(limit)
-		if err != nil {
-			httpResponse(w, http.StatusBadRequest, "invalid limit param")
-			return
-		}
+	page, err := parsePage(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	offset := r.URL.Query().Get("offset")
-
-	var offsetInt uint64
-
-	if offset == "" {
-		offsetInt = defaultOffsetValue
-	} else {
-		offsetInt, err = parseUint64(offset)
-		if err != nil {
-			httpResponse(w, http.StatusBadRequest, "invalid offset param")
-			return
-		}
+	filter, err := parseRouteFilter(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	routes, err := rc.RouteUsecase.List(r.Context(), limitInt, offsetInt)
+	result, err := rc.RouteUsecase.List(r.Context(), page, filter)
 	if err != nil {
 		httpResponse(w, DomainErrorToHTTP(err), err.Error())
 		return
 	}
 
-	rc.Log.Debug("list routes", "routes:", routes)
+	rc.Log.Debug("list routes", "routes:", result.Routes, "next cursor:", result.NextCursor)
 
-	err = json.NewEncoder(w).Encode(routes)
+	setPageHeaders(w, r, result.NextCursor, result.Total)
+
+	err = json.NewEncoder(w).Encode(result.Routes)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -91,9 +82,15 @@ func (rc *RoutesController) GetRouteById(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	rc.Log.Debug("get route by id", "parsed id:", id)
+	patternId, err := parsePatternId(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	route, routeWaypoints, err := rc.RouteUsecase.GetById(r.Context(), parsedId)
+	rc.Log.Debug("get route by id", "parsed id:", id, "pattern id:", patternId)
+
+	route, routeWaypoints, err := rc.RouteUsecase.GetById(r.Context(), parsedId, patternId)
 	if err != nil {
 		httpResponse(w, DomainErrorToHTTP(err), err.Error())
 		return
@@ -154,6 +151,48 @@ func (rc *RoutesController) CreateRoute(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusCreated)
 }
 
+func (rc *RoutesController) UpdateRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	var route requests.UpdateRouteRequest
+	err = json.NewDecoder(r.Body).Decode(&route)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := route.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc.Log.Debug("update route", "parsed id:", id, "decoded route:", route)
+
+	updated, err := rc.RouteUsecase.Update(r.Context(), parsedId, mapper.UpdateRouteRequestToDomain(route))
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	rc.Log.Debug("route updated", "route:", updated)
+
+	err = json.NewEncoder(w).Encode(updated)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
 func (rc *RoutesController) DeleteRoute(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 	id := chi.URLParam(r, "id")
@@ -177,6 +216,493 @@ func (rc *RoutesController) DeleteRoute(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 }
 
+// RestoreRoute восстанавливает удаленный маршрут вместе с его остановками и возвращает его.
+func (rc *RoutesController) RestoreRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	rc.Log.Debug("restore route", "parsed id:", id)
+
+	route, err := rc.RouteUsecase.Restore(r.Context(), parsedId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(route)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc *RoutesController) ReverseRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	radius := rc.ReverseRadius
+	if rs := r.URL.Query().Get("radius"); rs != "" {
+		radius, err = parseFloat(rs)
+		if err != nil || radius <= 0 {
+			httpResponse(w, http.StatusBadRequest, "invalid radius parameter")
+			return
+		}
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	rc.Log.Debug("reverse route", "parsed id:", id, "radius:", radius, "dry run:", dryRun)
+
+	reversed, err := rc.RouteUsecase.Reverse(r.Context(), parsedId, radius, dryRun)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	rc.Log.Debug("route reversed", "route:", reversed.Route, "unmatched:", reversed.Unmatched)
+
+	if dryRun {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	_ = json.NewEncoder(w).Encode(responses.ReverseRouteResponse{
+		Route:     reversed.Route,
+		Waypoints: reversed.Waypoints,
+		Unmatched: reversed.Unmatched,
+	})
+}
+
+func (rc *RoutesController) ListNear(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	lat := r.URL.Query().Get("lat")
+	lon := r.URL.Query().Get("lon")
+
+	if lat == "" || lon == "" {
+		httpResponse(w, http.StatusBadRequest, "no lat & lon provided")
+		return
+	}
+
+	latf, err := parseFloat(lat)
+	if err != nil || latf < -90 || latf > 90 {
+		httpResponse(w, http.StatusBadRequest, "invalid lat parameter")
+		return
+	}
+
+	lonf, err := parseFloat(lon)
+	if err != nil || lonf < -180 || lonf > 180 {
+		httpResponse(w, http.StatusBadRequest, "invalid lon parameter")
+		return
+	}
+
+	radius := float64(defaultNearRadiusValue)
+	if rs := r.URL.Query().Get("radius"); rs != "" {
+		radius, err = parseFloat(rs)
+		if err != nil || radius <= 0 || radius > maxRadiusValue {
+			httpResponse(w, http.StatusBadRequest, "invalid radius parameter")
+			return
+		}
+	}
+
+	rc.Log.Debug("list routes near", "lat:", lat, "lon:", lon, "radius:", radius)
+
+	routes, err := rc.RouteUsecase.Near(r.Context(), latf, lonf, radius)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	rc.Log.Debug("list routes near", "routes:", len(routes))
+
+	err = json.NewEncoder(w).Encode(routes)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// History возвращает журнал изменений маршрута, новые первыми.
+func (rc *RoutesController) History(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	page, err := parsePage(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := rc.RouteUsecase.History(r.Context(), id, page)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	rc.Log.Debug("route history", "id:", id, "records:", len(result.Records), "next cursor:", result.NextCursor)
+
+	setPageHeaders(w, r, result.NextCursor, result.Total)
+
+	err = json.NewEncoder(w).Encode(responses.HistoryResponse{
+		Items:      result.Records,
+		NextCursor: result.NextCursor,
+		Total:      result.Total,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc *RoutesController) ListTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	rc.Log.Debug("list route translations", "parsed id:", id)
+
+	translations, err := rc.RouteUsecase.ListTranslations(r.Context(), parsedId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(translations)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc *RoutesController) SetTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	lang := i18n.Normalize(chi.URLParam(r, "lang"))
+	if lang == "" {
+		httpResponse(w, http.StatusBadRequest, "invalid lang")
+		return
+	}
+
+	var translation requests.SetTranslationRequest
+
+	err = json.NewDecoder(r.Body).Decode(&translation)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := translation.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc.Log.Debug("set route translation", "parsed id:", id, "lang:", lang, "name:", translation.Name)
+
+	err = rc.RouteUsecase.SetTranslation(r.Context(), parsedId, domain.Translation{Lang: lang, Name: translation.Name})
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc *RoutesController) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	lang := i18n.Normalize(chi.URLParam(r, "lang"))
+	if lang == "" {
+		httpResponse(w, http.StatusBadRequest, "invalid lang")
+		return
+	}
+
+	rc.Log.Debug("delete route translation", "parsed id:", id, "lang:", lang)
+
+	err = rc.RouteUsecase.DeleteTranslation(r.Context(), parsedId, lang)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc *RoutesController) ListPatterns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	rc.Log.Debug("list route patterns", "parsed id:", id)
+
+	patterns, err := rc.RouteUsecase.ListPatterns(r.Context(), parsedId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(patterns)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc *RoutesController) CreatePattern(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	var pattern requests.CreatePatternRequest
+	err = json.NewDecoder(r.Body).Decode(&pattern)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := pattern.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc.Log.Debug("create route pattern", "parsed id:", id, "decoded pattern:", pattern)
+
+	routePattern, waypointIds := mapper.CreatePatternRequestToDomain(parsedId, pattern)
+
+	created, err := rc.RouteUsecase.CreatePattern(r.Context(), routePattern, waypointIds)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	_ = json.NewEncoder(w).Encode(created)
+}
+
+func (rc *RoutesController) UpdatePattern(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	patternId := chi.URLParam(r, "pattern_id")
+
+	parsedPatternId, err := uuid.Parse(patternId)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid pattern uuid")
+		return
+	}
+
+	var pattern requests.UpdatePatternRequest
+	err = json.NewDecoder(r.Body).Decode(&pattern)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := pattern.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	rc.Log.Debug("update route pattern", "parsed id:", id, "pattern id:", patternId, "decoded pattern:", pattern)
+
+	updated, err := rc.RouteUsecase.UpdatePattern(r.Context(), parsedId, parsedPatternId, mapper.UpdatePatternRequestToDomain(pattern))
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(updated)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (rc *RoutesController) DeletePattern(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	patternId := chi.URLParam(r, "pattern_id")
+
+	parsedPatternId, err := uuid.Parse(patternId)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid pattern uuid")
+		return
+	}
+
+	rc.Log.Debug("delete route pattern", "parsed id:", id, "pattern id:", patternId)
+
+	err = rc.RouteUsecase.DeletePattern(r.Context(), parsedId, parsedPatternId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func parseRouteFilter(r *http.Request) (domain.RouteFilter, error) {
+	var filter domain.RouteFilter
+
+	query := r.URL.Query()
+
+	filter.VehicleType = query.Get("vehicle_type")
+	if len(filter.VehicleType) > maxTypeCodeLength {
+		return domain.RouteFilter{}, errors.New("invalid vehicle_type param")
+	}
+
+	filter.RouteType = query.Get("route_type")
+	if len(filter.RouteType) > maxTypeCodeLength {
+		return domain.RouteFilter{}, errors.New("invalid route_type param")
+	}
+
+	filter.NamePrefix = query.Get("name")
+	if len(filter.NamePrefix) > 256 {
+		return domain.RouteFilter{}, errors.New("invalid name param")
+	}
+
+	if priceMin := query.Get("price_min"); priceMin != "" {
+		p, err := parseInt(priceMin)
+		if err != nil || p < 0 {
+			return domain.RouteFilter{}, errors.New("invalid price_min param")
+		}
+		filter.PriceMin = &p
+	}
+
+	if priceMax := query.Get("price_max"); priceMax != "" {
+		p, err := parseInt(priceMax)
+		if err != nil || p < 0 {
+			return domain.RouteFilter{}, errors.New("invalid price_max param")
+		}
+		filter.PriceMax = &p
+	}
+
+	if filter.PriceMin != nil && filter.PriceMax != nil && *filter.PriceMin > *filter.PriceMax {
+		return domain.RouteFilter{}, errors.New("price_min is greater than price_max")
+	}
+
+	if waypointId := query.Get("waypoint_id"); waypointId != "" {
+		id, err := uuid.Parse(waypointId)
+		if err != nil {
+			return domain.RouteFilter{}, errors.New("invalid waypoint_id param")
+		}
+		filter.WaypointID = &id
+	}
+
+	filter.SortBy = query.Get("sort")
+	if filter.SortBy == "" {
+		filter.SortBy = domain.RouteSortName
+	}
+
+	if !domain.ValidRouteSort(filter.SortBy) {
+		return domain.RouteFilter{}, errors.New("invalid sort param")
+	}
+
+	switch query.Get("order") {
+	case "", "asc":
+	case "desc":
+		filter.SortDesc = true
+	default:
+		return domain.RouteFilter{}, errors.New("invalid order param")
+	}
+
+	return filter, nil
+}
+
+// parsePatternId разбирает необязательный параметр pattern_id. Без параметра - основной вариант маршрута (uuid.Nil).
+func parsePatternId(r *http.Request) (uuid.UUID, error) {
+	patternId := r.URL.Query().Get("pattern_id")
+	if patternId == "" {
+		return uuid.Nil, nil
+	}
+
+	parsed, err := uuid.Parse(patternId)
+	if err != nil {
+		return uuid.Nil, errors.New("invalid pattern_id param")
+	}
+
+	return parsed, nil
+}
+
 func parseFloat(s string) (float64, error) {
 	f, err := strconv.ParseFloat(s, 64)
 	if err != nil {
@@ -200,3 +726,16 @@ func parseUint64(s string) (uint64, error) {
 	}
 	return i, nil
 }
+
+// parseOptionalBool возвращает nil для пустой строки.
+func parseOptionalBool(s string) (*bool, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
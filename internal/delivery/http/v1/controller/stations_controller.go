@@ -0,0 +1,289 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/requests"
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/responses"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultClusterRadiusValue     = 150
+	defaultClusterSimilarityValue = 0.6
+)
+
+type StationsController struct {
+	Log            logger.Logger
+	StationUsecase domain.StationsUsecase
+}
+
+func NewStationsController(log logger.Logger, su domain.StationsUsecase) *StationsController {
+	return &StationsController{
+		Log:            log,
+		StationUsecase: su,
+	}
+}
+
+func (sc *StationsController) List(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	stations, err := sc.StationUsecase.List(r.Context())
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	sc.Log.Debug("list stations", "stations:", len(stations))
+
+	err = json.NewEncoder(w).Encode(stations)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (sc *StationsController) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	sc.Log.Debug("get station", "parsed id:", id)
+
+	station, waypoints, err := sc.StationUsecase.GetById(r.Context(), parsedId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(responses.GetStationByIdResponse{
+		Station:   station,
+		Waypoints: waypoints,
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (sc *StationsController) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var station requests.CreateStationRequest
+
+	err := json.NewDecoder(r.Body).Decode(&station)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := station.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sc.Log.Debug("create station", "decoded station:", station)
+
+	waypointIds := make([]uuid.UUID, 0, len(station.Waypoints))
+
+	for _, waypoint := range station.Waypoints {
+		parsedWaypointId, err := uuid.Parse(waypoint)
+		if err != nil {
+			httpResponse(w, http.StatusBadRequest, fmt.Sprintf("invalid waypoint id: %s", waypoint))
+			return
+		}
+
+		waypointIds = append(waypointIds, parsedWaypointId)
+	}
+
+	created, err := sc.StationUsecase.Create(r.Context(), domain.Station{Name: station.Name}, waypointIds)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	_ = json.NewEncoder(w).Encode(created)
+}
+
+func (sc *StationsController) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	var station requests.UpdateStationRequest
+
+	err = json.NewDecoder(r.Body).Decode(&station)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := station.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	sc.Log.Debug("update station", "parsed id:", id, "decoded station:", station)
+
+	err = sc.StationUsecase.Update(r.Context(), domain.Station{ID: parsedId, Name: station.Name})
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (sc *StationsController) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	sc.Log.Debug("delete station", "parsed id:", id)
+
+	err = sc.StationUsecase.Delete(r.Context(), parsedId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (sc *StationsController) AddMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	var member requests.AddStationMemberRequest
+
+	err = json.NewDecoder(r.Body).Decode(&member)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	parsedWaypointId, err := uuid.Parse(member.WaypointID)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid waypoint id")
+		return
+	}
+
+	sc.Log.Debug("add station member", "parsed id:", id, "waypoint id:", member.WaypointID)
+
+	err = sc.StationUsecase.AddMember(r.Context(), parsedId, parsedWaypointId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (sc *StationsController) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	waypointId := chi.URLParam(r, "waypoint_id")
+
+	parsedWaypointId, err := uuid.Parse(waypointId)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid waypoint uuid")
+		return
+	}
+
+	sc.Log.Debug("remove station member", "parsed id:", id, "waypoint id:", waypointId)
+
+	err = sc.StationUsecase.RemoveMember(r.Context(), parsedId, parsedWaypointId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (sc *StationsController) Proposals(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	params := domain.SimilarityParams{
+		Radius:        defaultClusterRadiusValue,
+		MinSimilarity: defaultClusterSimilarityValue,
+	}
+
+	if radius := r.URL.Query().Get("radius"); radius != "" {
+		radiusf, err := parseFloat(radius)
+		if err != nil || radiusf <= 0 || radiusf > maxRadiusValue {
+			httpResponse(w, http.StatusBadRequest, "invalid radius parameter")
+			return
+		}
+		params.Radius = radiusf
+	}
+
+	if similarity := r.URL.Query().Get("similarity"); similarity != "" {
+		similarityf, err := parseFloat(similarity)
+		if err != nil || similarityf <= 0 || similarityf > 1 {
+			httpResponse(w, http.StatusBadRequest, "invalid similarity parameter")
+			return
+		}
+		params.MinSimilarity = similarityf
+	}
+
+	sc.Log.Debug("propose stations", "radius:", params.Radius, "similarity:", params.MinSimilarity)
+
+	proposals, err := sc.StationUsecase.Propose(r.Context(), params)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(proposals)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
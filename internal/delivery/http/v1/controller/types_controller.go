@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/mapper"
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/requests"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// Максимальная длина кода типа транспорта или маршрута.
+const maxTypeCodeLength = 32
+
+// TypesController обслуживает один справочник типов (типы транспорта или типы маршрутов).
+type TypesController struct {
+	Log          logger.Logger
+	TypesUsecase domain.TypesUsecase
+	What         string // Название справочника в логах
+}
+
+func NewTypesController(log logger.Logger, tu domain.TypesUsecase, what string) *TypesController {
+	return &TypesController{
+		Log:          log,
+		TypesUsecase: tu,
+		What:         what,
+	}
+}
+
+func (tc *TypesController) List(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	entries, err := tc.TypesUsecase.List(r.Context())
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	tc.Log.Debug("list "+tc.What, "entries:", len(entries))
+
+	err = json.NewEncoder(w).Encode(entries)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (tc *TypesController) Get(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	code := chi.URLParam(r, "code")
+
+	tc.Log.Debug("get "+tc.What, "code:", code)
+
+	entry, err := tc.TypesUsecase.Get(r.Context(), code)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(entry)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (tc *TypesController) Create(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var entry requests.CreateTypeEntryRequest
+
+	err := json.NewDecoder(r.Body).Decode(&entry)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := entry.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tc.Log.Debug("create "+tc.What, "decoded entry:", entry)
+
+	created := mapper.TypeEntryRequestToDomain(entry.Code, entry.UpdateTypeEntryRequest)
+
+	err = tc.TypesUsecase.Create(r.Context(), created)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+
+	_ = json.NewEncoder(w).Encode(created)
+}
+
+func (tc *TypesController) Update(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	code := chi.URLParam(r, "code")
+
+	var entry requests.UpdateTypeEntryRequest
+
+	err := json.NewDecoder(r.Body).Decode(&entry)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := entry.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tc.Log.Debug("update "+tc.What, "code:", code, "decoded entry:", entry)
+
+	updated, err := tc.TypesUsecase.Update(r.Context(), mapper.TypeEntryRequestToDomain(code, entry))
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(updated)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (tc *TypesController) Delete(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	code := chi.URLParam(r, "code")
+
+	tc.Log.Debug("delete "+tc.What, "code:", code)
+
+	err := tc.TypesUsecase.Delete(r.Context(), code)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+)
+
+type ValidationController struct {
+	Log               logger.Logger
+	ValidationUsecase domain.ValidationUsecase
+}
+
+func NewValidationController(log logger.Logger, vu domain.ValidationUsecase) *ValidationController {
+	return &ValidationController{
+		Log:               log,
+		ValidationUsecase: vu,
+	}
+}
+
+// Validate проверяет сеть и возвращает отчет в JSON (по умолчанию) или текстом (format=text).
+func (vc *ValidationController) Validate(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "text" {
+		w.Header().Add("Content-Type", "application/json")
+		httpResponse(w, http.StatusBadRequest, "invalid format parameter")
+		return
+	}
+
+	params, err := parseValidationParams(r)
+	if err != nil {
+		w.Header().Add("Content-Type", "application/json")
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	vc.Log.Debug("validate network", "format:", format, "max gap:", params.MaxStopGap)
+
+	report, err := vc.ValidationUsecase.Validate(r.Context(), params)
+	if err != nil {
+		w.Header().Add("Content-Type", "application/json")
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	vc.Log.Debug("validate network", "errors:", report.Errors, "warnings:", report.Warns)
+
+	if format == "text" {
+		w.Header().Add("Content-Type", "text/plain; charset=utf-8")
+
+		if err := report.WriteText(w); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Add("Content-Type", "application/json")
+
+	err = json.NewEncoder(w).Encode(report)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// parseValidationParams читает параметры проверки сети (max_gap в метрах).
+func parseValidationParams(r *http.Request) (domain.ValidationParams, error) {
+	params := domain.ValidationParams{
+		MaxStopGap: domain.DefaultMaxStopGap,
+	}
+
+	if maxGap := r.URL.Query().Get("max_gap"); maxGap != "" {
+		maxGapf, err := parseFloat(maxGap)
+		if err != nil || maxGapf <= 0 {
+			return domain.ValidationParams{}, errors.New("invalid max_gap parameter")
+		}
+		params.MaxStopGap = maxGapf
+	}
+
+	return params, nil
+}
@@ -2,17 +2,36 @@ package controller
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/mapper"
 	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/requests"
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller/responses"
 	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/i18n"
 	"github.com/dzhordano/maps-api/pkg/logger"
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
-const defaultAmountValue = 1
+const (
+	defaultAmountValue    = 1
+	maxAmountValue        = 100
+	maxCollectAmountValue = 10 // CollectRoutes перебирает amount*amount пар точек
+	maxRadiusValue        = 50000
+	maxBatchSize          = 1000
+
+	defaultDuplicateRadiusValue     = 50
+	defaultDuplicateSimilarityValue = 0.5
+
+	defaultSearchLimitValue = 10
+	maxSearchLimitValue     = 50
+	maxSearchQueryLength    = 100
+)
 
 type WaypointsController struct {
 	Log             logger.Logger
@@ -55,47 +74,117 @@ func (wc *WaypointsController) Create(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusCreated)
 }
 
-func (wc *WaypointsController) List(w http.ResponseWriter, r *http.Request) {
+func (wc *WaypointsController) CreateBatch(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 
-	limit := r.URL.Query().Get("limit")
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = string(domain.BatchModeAllOrNothing)
+	}
 
-	var limitInt uint64
-	var err error
+	if !domain.ValidBatchMode(mode) {
+		httpResponse(w, http.StatusBadRequest, "invalid mode parameter")
+		return
+	}
 
-	if limit == "" {
-		limitInt = defaultLimitValue
-	} else {
-		limitInt, err = parseUint64(limit)
-		if err != nil {
-			httpResponse(w, http.StatusBadRequest, "invalid limit param")
-			return
-		}
+	var waypoints []requests.CreateWaypointRequest
+
+	err := json.NewDecoder(r.Body).Decode(&waypoints)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
 	}
 
-	offset := r.URL.Query().Get("offset")
+	if len(waypoints) == 0 || len(waypoints) > maxBatchSize {
+		httpResponse(w, http.StatusBadRequest, fmt.Sprintf("batch size must be between 1 and %d", maxBatchSize))
+		return
+	}
 
-	var offsetInt uint64
+	wc.Log.Debug("create waypoints batch", "mode:", mode, "size:", len(waypoints))
 
-	if offset == "" {
-		offsetInt = defaultOffsetValue
-	} else {
-		offsetInt, err = parseUint64(offset)
+	results := make([]responses.CreateWaypointsBatchItem, len(waypoints))
+
+	var (
+		valid        []domain.Waypoint
+		validIndexes []int
+	)
+
+	for i, waypoint := range waypoints {
+		results[i].Index = i
+
+		if err := waypoint.Validate(); err != nil {
+			if domain.BatchMode(mode) == domain.BatchModeAllOrNothing {
+				httpResponse(w, http.StatusBadRequest, fmt.Sprintf("waypoint %d: %s", i, err.Error()))
+				return
+			}
+
+			results[i].Error = err.Error()
+			continue
+		}
+
+		valid = append(valid, mapper.CreateWaypointRequestToDomain(waypoint))
+		validIndexes = append(validIndexes, i)
+	}
+
+	var created int
+
+	if len(valid) > 0 {
+		batchResults, err := wc.WaypointUsecase.CreateMany(r.Context(), valid, domain.BatchMode(mode))
 		if err != nil {
-			httpResponse(w, http.StatusBadRequest, "invalid offset param")
+			httpResponse(w, DomainErrorToHTTP(err), err.Error())
 			return
 		}
 
+		for i, res := range batchResults {
+			item := &results[validIndexes[i]]
+
+			if !res.Created {
+				item.Error = res.Error
+				continue
+			}
+
+			item.ID = res.ID.String()
+			created++
+		}
+	}
+
+	wc.Log.Debug("waypoints batch created", "created:", created)
+
+	w.WriteHeader(http.StatusCreated)
+
+	_ = json.NewEncoder(w).Encode(responses.CreateWaypointsBatchResponse{
+		Mode:    mode,
+		Created: created,
+		Results: results,
+	})
+}
+
+func (wc *WaypointsController) List(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	page, err := parsePage(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseWaypointFilter(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
-	waypoints, err := wc.WaypointUsecase.List(r.Context(), limitInt, offsetInt)
+
+	result, err := wc.WaypointUsecase.List(r.Context(), page, filter)
 	if err != nil {
 		httpResponse(w, DomainErrorToHTTP(err), err.Error())
 		return
 	}
 
-	wc.Log.Debug("list waypoints", "waypoints:", waypoints)
+	wc.Log.Debug("list waypoints", "waypoints:", result.Waypoints, "next cursor:", result.NextCursor)
 
-	err = json.NewEncoder(w).Encode(waypoints)
+	setPageHeaders(w, r, result.NextCursor, result.Total)
+
+	err = json.NewEncoder(w).Encode(result.Waypoints)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -160,18 +249,35 @@ func (wc *WaypointsController) GetNearest(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var amountInt int
-	if amount == "" {
-		amountInt = defaultAmountValue
-	} else {
-		amountInt, err = parseInt(amount)
-		if err != nil {
-			httpResponse(w, http.StatusBadRequest, "invalid amount parameter")
+	search := domain.NearestSearch{
+		Latitude:  latf,
+		Longitude: lonf,
+		Amount:    defaultAmountValue,
+	}
+
+	if amount != "" {
+		search.Amount, err = parseInt(amount)
+		if err != nil || search.Amount < 1 || search.Amount > maxAmountValue {
+			httpResponse(w, http.StatusBadRequest, fmt.Sprintf("amount must be between 1 and %d", maxAmountValue))
+			return
+		}
+	}
+
+	if radius := r.URL.Query().Get("radius"); radius != "" {
+		radiusf, err := parseFloat(radius)
+		if err != nil || radiusf <= 0 || radiusf > maxRadiusValue {
+			httpResponse(w, http.StatusBadRequest, "invalid radius parameter")
 			return
 		}
+		search.Radius = &radiusf
+	}
+
+	if err := parseNearestRouteFilter(r, &search); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	waypoints, err := wc.WaypointUsecase.GetOfNearest(r.Context(), amountInt, latf, lonf)
+	waypoints, err := wc.WaypointUsecase.GetOfNearest(r.Context(), search)
 	if err != nil {
 		httpResponse(w, DomainErrorToHTTP(err), err.Error())
 		return
@@ -179,6 +285,124 @@ func (wc *WaypointsController) GetNearest(w http.ResponseWriter, r *http.Request
 
 	wc.Log.Debug("getOfNearest waypoint", "waypoints:", waypoints)
 
+	resp := make([]responses.NearbyWaypoint, len(waypoints))
+	for i, waypoint := range waypoints {
+		resp[i] = responses.NearbyWaypoint{
+			Waypoint:  waypoint.Waypoint,
+			DistanceM: waypoint.Distance,
+			Bearing:   waypoint.Bearing,
+			Routes:    waypoint.Routes,
+		}
+	}
+
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) Search(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" || utf8.RuneCountInString(q) > maxSearchQueryLength {
+		httpResponse(w, http.StatusBadRequest, "invalid q parameter")
+		return
+	}
+
+	search := domain.WaypointSearch{
+		Query: q,
+		Limit: defaultSearchLimitValue,
+	}
+
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		limitInt, err := parseUint64(limit)
+		if err != nil || limitInt == 0 || limitInt > maxSearchLimitValue {
+			httpResponse(w, http.StatusBadRequest, "invalid limit param")
+			return
+		}
+		search.Limit = limitInt
+	}
+
+	lat := r.URL.Query().Get("lat")
+	lon := r.URL.Query().Get("lon")
+
+	if (lat == "") != (lon == "") {
+		httpResponse(w, http.StatusBadRequest, "lat & lon must be provided together")
+		return
+	}
+
+	if lat != "" {
+		latf, err := parseFloat(lat)
+		if err != nil || latf < -90 || latf > 90 {
+			httpResponse(w, http.StatusBadRequest, "invalid lat parameter")
+			return
+		}
+
+		lonf, err := parseFloat(lon)
+		if err != nil || lonf < -180 || lonf > 180 {
+			httpResponse(w, http.StatusBadRequest, "invalid lon parameter")
+			return
+		}
+
+		search.Latitude = &latf
+		search.Longitude = &lonf
+	}
+
+	wc.Log.Debug("search waypoints", "q:", q, "lat:", lat, "lon:", lon)
+
+	matches, err := wc.WaypointUsecase.Search(r.Context(), search)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	wc.Log.Debug("search waypoints", "matches:", len(matches))
+
+	err = json.NewEncoder(w).Encode(matches)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) ListWithin(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	var within requests.WaypointsWithinRequest
+
+	err := json.NewDecoder(r.Body).Decode(&within)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := within.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter, err := parseWaypointFilter(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wc.Log.Debug("list waypoints within", "type:", within.Type)
+
+	waypoints, err := wc.WaypointUsecase.ListWithin(r.Context(), mapper.WaypointsWithinRequestToDomain(within), filter)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	wc.Log.Debug("list waypoints within", "waypoints:", len(waypoints))
+
 	err = json.NewEncoder(w).Encode(waypoints)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -255,7 +479,8 @@ func (wc *WaypointsController) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
-func (wc *WaypointsController) ListRoutes(w http.ResponseWriter, r *http.Request) {
+// Restore восстанавливает удаленную точку вместе с ее местами в маршрутах и возвращает ее.
+func (wc *WaypointsController) Restore(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 
 	id := chi.URLParam(r, "id")
@@ -266,17 +491,15 @@ func (wc *WaypointsController) ListRoutes(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	wc.Log.Debug("list routes", "parsed id:", id)
+	wc.Log.Debug("restore waypoint", "parsed id:", id)
 
-	routes, err := wc.WaypointUsecase.ListRoutes(r.Context(), parsedId)
+	waypoint, err := wc.WaypointUsecase.Restore(r.Context(), parsedId)
 	if err != nil {
 		httpResponse(w, DomainErrorToHTTP(err), err.Error())
 		return
 	}
 
-	wc.Log.Debug("list routes", "routes:", routes)
-
-	err = json.NewEncoder(w).Encode(routes)
+	err = json.NewEncoder(w).Encode(waypoint)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		return
@@ -285,36 +508,65 @@ func (wc *WaypointsController) ListRoutes(w http.ResponseWriter, r *http.Request
 	w.WriteHeader(http.StatusOK)
 }
 
-func (wc *WaypointsController) GetCommonRoutes(w http.ResponseWriter, r *http.Request) {
+// History возвращает журнал изменений остановки, новые первыми.
+func (wc *WaypointsController) History(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 
-	id := chi.URLParam(r, "id")
-
-	parsedId, err := uuid.Parse(id)
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
 	if err != nil {
 		httpResponse(w, http.StatusBadRequest, "invalid uuid")
 		return
 	}
 
-	wc.Log.Debug("get common routes", "parsed id:", parsedId)
+	page, err := parsePage(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	waypointId := chi.URLParam(r, "waypoint_id")
+	result, err := wc.WaypointUsecase.History(r.Context(), id, page)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
 
-	parsedWaypointId, err := uuid.Parse(waypointId)
+	wc.Log.Debug("waypoint history", "id:", id, "records:", len(result.Records), "next cursor:", result.NextCursor)
+
+	setPageHeaders(w, r, result.NextCursor, result.Total)
+
+	err = json.NewEncoder(w).Encode(responses.HistoryResponse{
+		Items:      result.Records,
+		NextCursor: result.NextCursor,
+		Total:      result.Total,
+	})
 	if err != nil {
-		httpResponse(w, http.StatusBadRequest, "invalid waypoint uuid")
+		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	wc.Log.Debug("get common routes", "parsed waypoint id:", parsedWaypointId)
+	w.WriteHeader(http.StatusOK)
+}
 
-	routes, err := wc.WaypointUsecase.CommonRoutes(r.Context(), parsedId, parsedWaypointId)
+func (wc *WaypointsController) ListRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	wc.Log.Debug("list routes", "parsed id:", id)
+
+	routes, err := wc.WaypointUsecase.ListRoutes(r.Context(), parsedId)
 	if err != nil {
 		httpResponse(w, DomainErrorToHTTP(err), err.Error())
 		return
 	}
 
-	wc.Log.Debug("get common routes", "waypoints:", routes)
+	wc.Log.Debug("list routes", "routes:", routes)
 
 	err = json.NewEncoder(w).Encode(routes)
 	if err != nil {
@@ -325,61 +577,601 @@ func (wc *WaypointsController) GetCommonRoutes(w http.ResponseWriter, r *http.Re
 	w.WriteHeader(http.StatusOK)
 }
 
-func (wc *WaypointsController) CollectRoutes(w http.ResponseWriter, r *http.Request) {
+func (wc *WaypointsController) AttachRoute(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 
-	amount := r.URL.Query().Get("amount")
+	id := chi.URLParam(r, "id")
 
-	var waypointsAmount int
-	var err error
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
 
-	if amount == "" {
-		waypointsAmount = defaultAmountValue
-	} else {
-		waypointsAmount, err = parseInt(r.URL.Query().Get("amount"))
-		if err != nil {
-			httpResponse(w, http.StatusBadRequest, "invalid amount parameter")
-			return
-		}
+	var route requests.AttachRouteRequest
+
+	err = json.NewDecoder(r.Body).Decode(&route)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
 	}
 
-	lat1f, err := parseFloat(r.URL.Query().Get("lat1"))
+	if err := route.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wc.Log.Debug("attach route", "parsed id:", id, "decoded route:", route)
+
+	err = wc.WaypointUsecase.AttachRoute(r.Context(), mapper.AttachRouteRequestToDomain(parsedId, route))
 	if err != nil {
-		httpResponse(w, http.StatusBadRequest, "invalid lat1 parameter")
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
 		return
 	}
 
-	lon1f, err := parseFloat(r.URL.Query().Get("lon1"))
+	wc.Log.Debug("route attached", "waypoint id:", id, "route id:", route.RouteID)
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (wc *WaypointsController) DetachRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
 	if err != nil {
-		httpResponse(w, http.StatusBadRequest, "invalid lon1 parameter")
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
 		return
 	}
 
-	lat2f, err := parseFloat(r.URL.Query().Get("lat2"))
+	routeId := chi.URLParam(r, "route_id")
+
+	parsedRouteId, err := uuid.Parse(routeId)
 	if err != nil {
-		httpResponse(w, http.StatusBadRequest, "invalid lat2 parameter")
+		httpResponse(w, http.StatusBadRequest, "invalid route uuid")
 		return
 	}
 
-	lon2f, err := parseFloat(r.URL.Query().Get("lon2"))
+	patternId, err := parsePatternId(r)
 	if err != nil {
-		httpResponse(w, http.StatusBadRequest, "invalid lon2 parameter")
+		httpResponse(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	routes, err := wc.WaypointUsecase.CollectRoutes(r.Context(), waypointsAmount, lat1f, lon1f, lat2f, lon2f)
+	wc.Log.Debug("detach route", "parsed id:", id, "parsed route id:", routeId, "pattern id:", patternId)
+
+	err = wc.WaypointUsecase.DetachRoute(r.Context(), parsedId, parsedRouteId, patternId)
 	if err != nil {
 		httpResponse(w, DomainErrorToHTTP(err), err.Error())
 		return
 	}
 
-	wc.Log.Debug("collect routes", "routes:", routes)
+	wc.Log.Debug("route detached", "waypoint id:", id, "route id:", routeId)
 
-	err = json.NewEncoder(w).Encode(routes)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) MoveRoute(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
 		return
 	}
 
-	w.WriteHeader(http.StatusOK)
+	routeId := chi.URLParam(r, "route_id")
+
+	parsedRouteId, err := uuid.Parse(routeId)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid route uuid")
+		return
+	}
+
+	patternId, err := parsePatternId(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var move requests.MoveRouteRequest
+
+	err = json.NewDecoder(r.Body).Decode(&move)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := move.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wc.Log.Debug("move route", "parsed id:", id, "parsed route id:", routeId, "pattern id:", patternId, "route number:", move.RouteNumber)
+
+	err = wc.WaypointUsecase.MoveRoute(r.Context(), parsedId, parsedRouteId, patternId, move.RouteNumber)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	wc.Log.Debug("route moved", "waypoint id:", id, "route id:", routeId)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) SetStopRules(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	routeId := chi.URLParam(r, "route_id")
+
+	parsedRouteId, err := uuid.Parse(routeId)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid route uuid")
+		return
+	}
+
+	patternId, err := parsePatternId(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var rules requests.StopRulesRequest
+
+	err = json.NewDecoder(r.Body).Decode(&rules)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := rules.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wc.Log.Debug("set stop rules", "parsed id:", id, "parsed route id:", routeId, "pattern id:", patternId, "decoded rules:", rules)
+
+	pickupType, dropOffType := mapper.StopRulesRequestToDomain(rules)
+
+	err = wc.WaypointUsecase.SetStopRules(r.Context(), parsedId, parsedRouteId, patternId, pickupType, dropOffType)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	wc.Log.Debug("stop rules set", "waypoint id:", id, "route id:", routeId)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) SetStopValidity(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	routeId := chi.URLParam(r, "route_id")
+
+	parsedRouteId, err := uuid.Parse(routeId)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid route uuid")
+		return
+	}
+
+	patternId, err := parsePatternId(r)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var validity requests.Validity
+
+	err = json.NewDecoder(r.Body).Decode(&validity)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := validity.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wc.Log.Debug("set stop validity", "parsed id:", id, "parsed route id:", routeId, "pattern id:", patternId, "decoded validity:", validity)
+
+	err = wc.WaypointUsecase.SetStopValidity(r.Context(), parsedId, parsedRouteId, patternId, mapper.ValidityRequestToDomain(validity))
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	wc.Log.Debug("stop validity set", "waypoint id:", id, "route id:", routeId)
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) GetCommonRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	wc.Log.Debug("get common routes", "parsed id:", parsedId)
+
+	waypointId := chi.URLParam(r, "waypoint_id")
+
+	parsedWaypointId, err := uuid.Parse(waypointId)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid waypoint uuid")
+		return
+	}
+
+	wc.Log.Debug("get common routes", "parsed waypoint id:", parsedWaypointId)
+
+	routes, err := wc.WaypointUsecase.CommonRoutes(r.Context(), parsedId, parsedWaypointId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	wc.Log.Debug("get common routes", "waypoints:", routes)
+
+	err = json.NewEncoder(w).Encode(routes)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) CollectRoutes(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	amount := r.URL.Query().Get("amount")
+
+	var waypointsAmount int
+	var err error
+
+	if amount == "" {
+		waypointsAmount = defaultAmountValue
+	} else {
+		waypointsAmount, err = parseInt(r.URL.Query().Get("amount"))
+		if err != nil || waypointsAmount < 1 || waypointsAmount > maxCollectAmountValue {
+			httpResponse(w, http.StatusBadRequest, fmt.Sprintf("amount must be between 1 and %d", maxCollectAmountValue))
+			return
+		}
+	}
+
+	lat1f, err := parseFloat(r.URL.Query().Get("lat1"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid lat1 parameter")
+		return
+	}
+
+	lon1f, err := parseFloat(r.URL.Query().Get("lon1"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid lon1 parameter")
+		return
+	}
+
+	lat2f, err := parseFloat(r.URL.Query().Get("lat2"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid lat2 parameter")
+		return
+	}
+
+	lon2f, err := parseFloat(r.URL.Query().Get("lon2"))
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid lon2 parameter")
+		return
+	}
+
+	routes, err := wc.WaypointUsecase.CollectRoutes(r.Context(), waypointsAmount, lat1f, lon1f, lat2f, lon2f)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	wc.Log.Debug("collect routes", "routes:", routes)
+
+	err = json.NewEncoder(w).Encode(routes)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) ListDuplicates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	params := domain.SimilarityParams{
+		Radius:        defaultDuplicateRadiusValue,
+		MinSimilarity: defaultDuplicateSimilarityValue,
+	}
+
+	if radius := r.URL.Query().Get("radius"); radius != "" {
+		radiusf, err := parseFloat(radius)
+		if err != nil || radiusf <= 0 || radiusf > maxRadiusValue {
+			httpResponse(w, http.StatusBadRequest, "invalid radius parameter")
+			return
+		}
+		params.Radius = radiusf
+	}
+
+	if similarity := r.URL.Query().Get("similarity"); similarity != "" {
+		similarityf, err := parseFloat(similarity)
+		if err != nil || similarityf <= 0 || similarityf > 1 {
+			httpResponse(w, http.StatusBadRequest, "invalid similarity parameter")
+			return
+		}
+		params.MinSimilarity = similarityf
+	}
+
+	wc.Log.Debug("list duplicate waypoints", "radius:", params.Radius, "similarity:", params.MinSimilarity)
+
+	pairs, err := wc.WaypointUsecase.FindDuplicates(r.Context(), params)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(pairs)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) Merge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	var merge requests.MergeWaypointRequest
+
+	err = json.NewDecoder(r.Body).Decode(&merge)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	parsedMergedId, err := uuid.Parse(merge.WaypointID)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid waypoint id")
+		return
+	}
+
+	wc.Log.Debug("merge waypoints", "survivor id:", id, "merged id:", merge.WaypointID)
+
+	err = wc.WaypointUsecase.Merge(r.Context(), parsedId, parsedMergedId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) ListTranslations(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	wc.Log.Debug("list waypoint translations", "parsed id:", id)
+
+	translations, err := wc.WaypointUsecase.ListTranslations(r.Context(), parsedId)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	err = json.NewEncoder(w).Encode(translations)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) SetTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	lang := i18n.Normalize(chi.URLParam(r, "lang"))
+	if lang == "" {
+		httpResponse(w, http.StatusBadRequest, "invalid lang")
+		return
+	}
+
+	var translation requests.SetTranslationRequest
+
+	err = json.NewDecoder(r.Body).Decode(&translation)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := translation.Validate(); err != nil {
+		httpResponse(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wc.Log.Debug("set waypoint translation", "parsed id:", id, "lang:", lang, "name:", translation.Name)
+
+	err = wc.WaypointUsecase.SetTranslation(r.Context(), parsedId, domain.Translation{Lang: lang, Name: translation.Name})
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (wc *WaypointsController) DeleteTranslation(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Content-Type", "application/json")
+
+	id := chi.URLParam(r, "id")
+
+	parsedId, err := uuid.Parse(id)
+	if err != nil {
+		httpResponse(w, http.StatusBadRequest, "invalid uuid")
+		return
+	}
+
+	lang := i18n.Normalize(chi.URLParam(r, "lang"))
+	if lang == "" {
+		httpResponse(w, http.StatusBadRequest, "invalid lang")
+		return
+	}
+
+	wc.Log.Debug("delete waypoint translation", "parsed id:", id, "lang:", lang)
+
+	err = wc.WaypointUsecase.DeleteTranslation(r.Context(), parsedId, lang)
+	if err != nil {
+		httpResponse(w, DomainErrorToHTTP(err), err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func parseWaypointFilter(r *http.Request) (domain.WaypointFilter, error) {
+	var (
+		filter domain.WaypointFilter
+		err    error
+	)
+
+	query := r.URL.Query()
+
+	filter.WheelchairBoarding = query.Get("wheelchair_boarding")
+	if filter.WheelchairBoarding != "" && !domain.ValidWheelchairBoarding(filter.WheelchairBoarding) {
+		return domain.WaypointFilter{}, errors.New("invalid wheelchair_boarding param")
+	}
+
+	filter.PlatformCode = query.Get("platform_code")
+
+	for param, dst := range map[string]**bool{
+		"shelter":        &filter.Shelter,
+		"bench":          &filter.Bench,
+		"lighting":       &filter.Lighting,
+		"tactile_paving": &filter.TactilePaving,
+	} {
+		if *dst, err = parseOptionalBool(query.Get(param)); err != nil {
+			return domain.WaypointFilter{}, fmt.Errorf("invalid %s param", param)
+		}
+	}
+
+	if bbox := query.Get("bbox"); bbox != "" {
+		if filter.BBox, err = parseBBox(bbox); err != nil {
+			return domain.WaypointFilter{}, errors.New("invalid bbox param")
+		}
+	}
+
+	return filter, nil
+}
+
+// parseBBox разбирает "minLon,minLat,maxLon,maxLat". Прямоугольники через антимеридиан не поддерживаются.
+func parseBBox(s string) (*domain.BBox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("bbox must have 4 coordinates")
+	}
+
+	var coords [4]float64
+	for i, part := range parts {
+		f, err := parseFloat(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		coords[i] = f
+	}
+
+	bbox := domain.BBox{MinLon: coords[0], MinLat: coords[1], MaxLon: coords[2], MaxLat: coords[3]}
+
+	if bbox.MinLon < -180 || bbox.MaxLon > 180 || bbox.MinLat < -90 || bbox.MaxLat > 90 ||
+		bbox.MinLon > bbox.MaxLon || bbox.MinLat > bbox.MaxLat {
+		return nil, errors.New("bbox is out of range")
+	}
+
+	return &bbox, nil
+}
+
+// parseNearestRouteFilter разбирает фильтры поиска ближайших точек по проходящим через них маршрутам.
+func parseNearestRouteFilter(r *http.Request, search *domain.NearestSearch) error {
+	query := r.URL.Query()
+
+	search.VehicleType = query.Get("vehicle_type")
+	if len(search.VehicleType) > maxTypeCodeLength {
+		return errors.New("invalid vehicle_type param")
+	}
+
+	search.RouteType = query.Get("route_type")
+	if len(search.RouteType) > maxTypeCodeLength {
+		return errors.New("invalid route_type param")
+	}
+
+	if routeID := query.Get("route_id"); routeID != "" {
+		id, err := uuid.Parse(routeID)
+		if err != nil {
+			return errors.New("invalid route_id param")
+		}
+		search.RouteID = &id
+	}
+
+	if routeKind := query.Get("route_kind"); routeKind != "" {
+		kind, err := parseInt(routeKind)
+		if err != nil || kind < 0 || kind > 2 {
+			return errors.New("invalid route_kind param")
+		}
+		search.RouteKind = &kind
+	}
+
+	return nil
 }
@@ -0,0 +1,11 @@
+package route
+
+import (
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewAdminRouter(log logger.Logger, vc *controller.ValidationController, r chi.Router) {
+	r.Get("/admin/validate", vc.Validate) // Проверка качества данных сети (format: json, text; max_gap в метрах).
+}
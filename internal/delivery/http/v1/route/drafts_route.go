@@ -0,0 +1,40 @@
+package route
+
+import (
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// Изменения, которые можно добавить в черновик (параметр draft). Остальные изменения с draft отклоняются.
+var draftStageable = map[string]bool{
+	"POST /waypoints":        true,
+	"PUT /waypoints/{id}":    true,
+	"DELETE /waypoints/{id}": true,
+
+	"POST /routes":        true,
+	"PATCH /routes/{id}":  true,
+	"DELETE /routes/{id}": true,
+
+	"POST /routes/{id}/patterns":                true,
+	"PATCH /routes/{id}/patterns/{pattern_id}":  true,
+	"DELETE /routes/{id}/patterns/{pattern_id}": true,
+
+	"POST /waypoints/{id}/routes":                    true,
+	"PATCH /waypoints/{id}/routes/{route_id}":        true,
+	"DELETE /waypoints/{id}/routes/{route_id}":       true,
+	"PUT /waypoints/{id}/routes/{route_id}/rules":    true,
+	"PUT /waypoints/{id}/routes/{route_id}/validity": true,
+}
+
+func NewDraftsRouter(log logger.Logger, dc *controller.DraftsController, r chi.Router) {
+	r.Get("/drafts", dc.List)     // Получение черновиков (фильтр status: open, published, discarded).
+	r.Post("/drafts", dc.Create)  // Создание черновика.
+	r.Get("/drafts/{id}", dc.Get) // Получение черновика вместе с изменениями по порядку.
+
+	r.Delete("/drafts/{id}/changes/{seq}", dc.RemoveChange) // Удаление изменения из черновика (например, больше не применимого).
+
+	r.Get("/drafts/{id}/validate", dc.Validate) // Проверка сети с примененным черновиком (как /admin/validate).
+	r.Post("/drafts/{id}/publish", dc.Publish)  // Применение всех изменений черновика к сети одной транзакцией.
+	r.Post("/drafts/{id}/discard", dc.Discard)  // Отмена черновика без изменения сети.
+}
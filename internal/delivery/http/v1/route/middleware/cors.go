@@ -9,9 +9,9 @@ import (
 func CORS() func(next http.Handler) http.Handler {
 	return cors.Handler(cors.Options{
 		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders: []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders: []string{"Link"},
+		AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"Accept", "Accept-Language", "Authorization", "Content-Type", "X-CSRF-Token", "X-Actor", "X-Request-Id"},
+		ExposedHeaders: []string{"Link", "Content-Language", "X-Total-Count", "X-Next-Cursor", "X-Request-Id"},
 		MaxAge:         300,
 	})
 }
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/dzhordano/maps-api/pkg/i18n"
+)
+
+// Language определяет язык ответа по параметру lang или заголовку Accept-Language.
+func Language() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			lang := i18n.Normalize(r.URL.Query().Get("lang"))
+
+			if lang == "" {
+				if langs := i18n.ParseAcceptLanguage(r.Header.Get("Accept-Language")); len(langs) > 0 {
+					lang = langs[0]
+				}
+			}
+
+			if lang == "" {
+				lang = i18n.SourceLang
+			}
+
+			w.Header().Set("Content-Language", lang)
+
+			next.ServeHTTP(w, r.WithContext(i18n.WithLang(r.Context(), lang)))
+		})
+	}
+}
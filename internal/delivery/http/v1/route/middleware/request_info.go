@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+)
+
+const (
+	maxActorLength     = 256
+	maxRequestIDLength = 128
+)
+
+// RequestInfo передает в журнал изменений автора (заголовок X-Actor) и id запроса.
+// Id запроса берется из X-Request-Id или создается и возвращается в том же заголовке ответа.
+// Автора сообщает сам клиент, заголовок не проверяется: в API нет аутентификации, и автор в журнале
+// справочный, а не доказательство. Когда появится аутентификация, автора нужно брать из нее.
+func RequestInfo() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get("X-Request-Id")
+			if requestID == "" || len(requestID) > maxRequestIDLength {
+				requestID = uuid.NewString()
+			}
+
+			w.Header().Set("X-Request-Id", requestID)
+
+			actor := r.Header.Get("X-Actor")
+			if len(actor) > maxActorLength {
+				actor = strings.ToValidUTF8(actor[:maxActorLength], "")
+			}
+
+			ctx := domain.WithRequestID(r.Context(), requestID)
+			ctx = domain.WithActor(ctx, actor)
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
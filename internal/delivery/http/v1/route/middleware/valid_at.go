@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+// ValidAt читает дату из параметра at (YYYY-MM-DD): ответы показывают сеть на эту дату.
+// Без параметра возвращаются все остановки и маршруты независимо от периода действия.
+// Параметр учитывается только в запросах с методами methods (чтение): изменение с at применилось бы,
+// а чтение результата и снимки журнала изменений не нашли бы запись вне периода действия.
+func ValidAt(methods ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			at := r.URL.Query().Get("at")
+			if at == "" || !slices.Contains(methods, r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			parsed, err := time.Parse(domain.DateLayout, at)
+			if err != nil {
+				w.Header().Add("Content-Type", "application/json")
+				w.WriteHeader(http.StatusBadRequest)
+				_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid at"})
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(domain.WithValidAt(r.Context(), parsed)))
+		})
+	}
+}
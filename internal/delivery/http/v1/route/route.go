@@ -10,7 +10,7 @@ import (
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
-func SetupV1(log logger.Logger, wc *controller.WaypointsController, rc *controller.RoutesController, r *chi.Mux) {
+func SetupV1(log logger.Logger, wc *controller.WaypointsController, rc *controller.RoutesController, sc *controller.StationsController, vc *controller.ValidationController, vtc, rtc *controller.TypesController, dc *controller.DraftsController, r *chi.Mux) {
 	v1 := chi.NewRouter()
 
 	// Инициализация пути получения файла с документацией
@@ -24,8 +24,21 @@ func SetupV1(log logger.Logger, wc *controller.WaypointsController, rc *controll
 
 	v1.Group(func(r chi.Router) {
 		r.Use(middleware.CORS())
-
-		NewWaypointsRouter(log, wc, r)
-		NewRoutesRouter(log, rc, r)
+		r.Use(middleware.Language())
+		r.Use(middleware.RequestInfo())
+		r.Use(middleware.ValidAt(http.MethodGet))
+
+		NewDraftsRouter(log, dc, r)
+
+		// Чтение и изменение сети через черновик (параметр draft)
+		r.Group(func(r chi.Router) {
+			r.Use(dc.Draft(v1, draftStageable))
+
+			NewWaypointsRouter(log, wc, r)
+			NewRoutesRouter(log, rc, r)
+			NewStationsRouter(log, sc, r)
+			NewAdminRouter(log, vc, r)
+			NewTypesRouter(log, vtc, rtc, r)
+		})
 	})
 }
@@ -7,9 +7,25 @@ import (
 )
 
 func NewRoutesRouter(log logger.Logger, rc *controller.RoutesController, r chi.Router) {
-	r.Get("/routes/{id}", rc.GetRouteById) // Получение маршрута по id. Также возврат всех остановок на маршруте.
+	r.Get("/routes/{id}", rc.GetRouteById) // Получение маршрута по id. Также возврат остановок варианта pattern_id (по умолчанию основного).
 	r.Get("/routes", rc.ListRoutes)        // Получение всех существующих маршрутов.
+	r.Get("/routes/near", rc.ListNear)     // Получение маршрутов с остановкой в радиусе от точки (оба направления вместе).
 
-	r.Post("/routes", rc.CreateRoute)        // Создание маршрута.
-	r.Delete("/routes/{id}", rc.DeleteRoute) // Удаление маршрута.
+	r.Post("/routes", rc.CreateRoute)               // Создание маршрута.
+	r.Patch("/routes/{id}", rc.UpdateRoute)         // Частичное обновление маршрута (название, цена, тип транспорта, тип маршрута).
+	r.Delete("/routes/{id}", rc.DeleteRoute)        // Удаление маршрута (мягкое: маршрут можно восстановить до очистки).
+	r.Post("/routes/{id}/restore", rc.RestoreRoute) // Восстановление удаленного маршрута вместе с его остановками.
+
+	r.Get("/routes/{id}/history", rc.History) // Журнал изменений маршрута (кто, когда, снимки до и после), включая изменения списка остановок.
+
+	r.Post("/routes/{id}/reverse", rc.ReverseRoute) // Построение обратного направления маршрута по остановкам напротив.
+
+	r.Get("/routes/{id}/patterns", rc.ListPatterns)                  // Получение вариантов маршрута (основной первым).
+	r.Post("/routes/{id}/patterns", rc.CreatePattern)                // Создание варианта маршрута (укороченный рейс, экспресс).
+	r.Patch("/routes/{id}/patterns/{pattern_id}", rc.UpdatePattern)  // Переименование варианта или назначение его основным.
+	r.Delete("/routes/{id}/patterns/{pattern_id}", rc.DeletePattern) // Удаление варианта (кроме основного).
+
+	r.Get("/routes/{id}/translations", rc.ListTranslations)            // Получение переводов названия маршрута.
+	r.Put("/routes/{id}/translations/{lang}", rc.SetTranslation)       // Добавление/изменение перевода названия маршрута.
+	r.Delete("/routes/{id}/translations/{lang}", rc.DeleteTranslation) // Удаление перевода названия маршрута.
 }
@@ -0,0 +1,19 @@
+package route
+
+import (
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewStationsRouter(log logger.Logger, sc *controller.StationsController, r chi.Router) {
+	r.Get("/stations", sc.List)                      // Получение всех станций.
+	r.Get("/stations/{id}", sc.Get)                  // Получение станции по id вместе с ее остановками.
+	r.Get("/stations/proposals", sc.Proposals)       // Предложения станций: группы похожих остановок рядом друг с другом (radius, similarity).
+	r.Post("/stations", sc.Create)                   // Создание станции из остановок.
+	r.Put("/stations/{id}", sc.Update)               // Переименование станции.
+	r.Delete("/stations/{id}", sc.Delete)            // Удаление станции (остановки остаются).
+	r.Post("/stations/{id}/waypoints", sc.AddMember) // Добавление остановки в станцию.
+
+	r.Delete("/stations/{id}/waypoints/{waypoint_id}", sc.RemoveMember) // Исключение остановки из станции.
+}
@@ -0,0 +1,21 @@
+package route
+
+import (
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+func NewTypesRouter(log logger.Logger, vtc, rtc *controller.TypesController, r chi.Router) {
+	r.Get("/vehicle-types", vtc.List)             // Справочник типов транспорта.
+	r.Get("/vehicle-types/{code}", vtc.Get)       // Получение типа транспорта по коду.
+	r.Post("/vehicle-types", vtc.Create)          // Добавление типа транспорта.
+	r.Put("/vehicle-types/{code}", vtc.Update)    // Изменение типа транспорта (код не изменяется).
+	r.Delete("/vehicle-types/{code}", vtc.Delete) // Удаление типа транспорта, если он не используется маршрутами.
+
+	r.Get("/route-types", rtc.List)             // Справочник типов маршрутов.
+	r.Get("/route-types/{code}", rtc.Get)       // Получение типа маршрута по коду.
+	r.Post("/route-types", rtc.Create)          // Добавление типа маршрута.
+	r.Put("/route-types/{code}", rtc.Update)    // Изменение типа маршрута (код не изменяется).
+	r.Delete("/route-types/{code}", rtc.Delete) // Удаление типа маршрута, если он не используется маршрутами.
+}
@@ -1,7 +1,10 @@
 package route
 
 import (
+	"net/http"
+
 	"github.com/dzhordano/maps-api/internal/delivery/http/v1/controller"
+	"github.com/dzhordano/maps-api/internal/delivery/http/v1/route/middleware"
 	"github.com/dzhordano/maps-api/pkg/logger"
 	"github.com/go-chi/chi/v5"
 )
@@ -11,14 +14,36 @@ func NewWaypointsRouter(log logger.Logger, wc *controller.WaypointsController, r
 	// Вернуть маршруты между двумя точками (в amount). Где от каждой точки до каждой другой возвращаются общие маршруты.
 	r.Get("/waypoints/route", wc.CollectRoutes)
 
-	r.Get("/waypoints", wc.List)               // Получение всех существующих точек.
-	r.Get("/waypoints/{id}", wc.Get)           // Получение точки по id c подробной информацией об остановке *пока никакой такой информации нету*.
-	r.Get("/waypoints/nearest", wc.GetNearest) // Получение ближайших точек от параметров (количество, широта, долгота).
+	r.Get("/waypoints", wc.List)                      // Получение всех существующих точек (с фильтрами по информации об остановке и bbox).
+	r.Get("/waypoints/{id}", wc.Get)                  // Получение точки по id c подробной информацией об остановке (доступность, навес, код платформы и т.д.).
+	r.Get("/waypoints/nearest", wc.GetNearest)        // Получение ближайших точек от параметров (количество, широта, долгота, радиус, фильтры по маршрутам).
+	r.Get("/waypoints/search", wc.Search)             // Нечеткий поиск точек по названию (кириллица или транслитерация), с учетом расстояния от lat/lon.
+	r.Get("/waypoints/duplicates", wc.ListDuplicates) // Поиск возможных дубликатов: пары остановок с похожими названиями рядом друг с другом.
+
+	r.Post("/waypoints", wc.Create)               // Создание новой точки (остановки).
+	r.Post("/waypoints:batch", wc.CreateBatch)    // Пакетное создание точек в одной транзакции (mode: all_or_nothing, best_effort).
+	r.Put("/waypoints/{id}", wc.Update)           // Обновление точки.
+	r.Delete("/waypoints/{id}", wc.Delete)        // Удаление точки (мягкое: точку можно восстановить до очистки).
+	r.Post("/waypoints/{id}/restore", wc.Restore) // Восстановление удаленной точки вместе с ее местами в маршрутах.
+
+	// Поиск по полигону - чтение через POST, поэтому параметр at учитывается и в нем.
+	r.With(middleware.ValidAt(http.MethodPost)).Post("/waypoints/within", wc.ListWithin) // Получение точек внутри полигона GeoJSON (район, тарифная зона).
+
+	r.Get("/waypoints/{id}/history", wc.History) // Журнал изменений точки (кто, когда, снимки до и после), включая изменения маршрутов через нее.
 
-	r.Post("/waypoints", wc.Create)        // Создание новой точки (остановки).
-	r.Put("/waypoints/{id}", wc.Update)    // Обновление точки.
-	r.Delete("/waypoints/{id}", wc.Delete) // Удаление точки.
+	r.Post("/waypoints/{id}/merge", wc.Merge) // Объединение дубликата с точкой: маршруты переносятся на {id}, id дубликата остается псевдонимом.
 
 	r.Get("/waypoints/{id}/routes", wc.ListRoutes)                    // Получение маршрутов, проходящих через остановку.
 	r.Get("/waypoints/{id}/routes/{waypoint_id}", wc.GetCommonRoutes) // Получение общих маршрутов между двумя остановками.
+
+	r.Post("/waypoints/{id}/routes", wc.AttachRoute)              // Добавление остановки в маршрут на заданную позицию.
+	r.Patch("/waypoints/{id}/routes/{route_id}", wc.MoveRoute)    // Перемещение остановки на другую позицию в маршруте.
+	r.Delete("/waypoints/{id}/routes/{route_id}", wc.DetachRoute) // Удаление остановки из маршрута.
+
+	r.Put("/waypoints/{id}/routes/{route_id}/rules", wc.SetStopRules)       // Правила посадки/высадки на остановке маршрута (regular, none, on_request).
+	r.Put("/waypoints/{id}/routes/{route_id}/validity", wc.SetStopValidity) // Период действия остановки в маршруте (valid_from, valid_to).
+
+	r.Get("/waypoints/{id}/translations", wc.ListTranslations)            // Получение переводов названия остановки.
+	r.Put("/waypoints/{id}/translations/{lang}", wc.SetTranslation)       // Добавление/изменение перевода названия остановки.
+	r.Delete("/waypoints/{id}/translations/{lang}", wc.DeleteTranslation) // Удаление перевода названия остановки.
 }
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Что изменялось в записи журнала.
+const (
+	AuditWaypoint = "waypoint"
+	AuditRoute    = "route"
+)
+
+// Действия журнала, которых нет среди видов изменений черновика: восстановление удаленных остановок и маршрутов
+// и объединение остановок. Остальные действия совпадают с видами изменений черновика.
+const (
+	AuditWaypointRestore = "waypoint_restore"
+	AuditRouteRestore    = "route_restore"
+	AuditWaypointMerge   = "waypoint_merge" // Записывается для обеих остановок
+)
+
+// Запись журнала изменений. Action совпадает с видом изменения черновика (waypoint_update, stop_move и т.д.)
+// или другим действием (waypoint_restore, waypoint_merge, pattern_update и т.д.).
+// Изменения списка остановок и вариантов записываются для маршрута, WaypointID указывает остановку.
+type AuditRecord struct {
+	ID         int64
+	EntityType string
+	EntityID   uuid.UUID
+	WaypointID *uuid.UUID
+	Action     string
+	Actor      string
+	RequestID  string
+	Before     json.RawMessage // null - записи еще не было
+	After      json.RawMessage // null - запись удалена
+	CreatedAt  time.Time
+}
+
+type AuditPage struct {
+	Records    []AuditRecord
+	NextCursor string // Пустой, если страница последняя
+	Total      int
+}
+
+type AuditRepository interface {
+	Add(ctx context.Context, record AuditRecord) error
+	// History возвращает изменения, новые первыми. История остановки включает изменения маршрутов, затронувшие эту остановку.
+	History(ctx context.Context, entityType string, id uuid.UUID, page Page) (AuditPage, error)
+	// Lock блокирует объект (точку или маршрут) до конца транзакции из контекста,
+	// чтобы параллельные изменения не записали в журнал одно и то же состояние до изменения.
+	Lock(ctx context.Context, entityType string, id uuid.UUID) error
+
+	// InTx выполняет fn в транзакции, чтобы изменение и запись о нем сохранялись вместе.
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type actorKey struct{}
+
+type requestIDKey struct{}
+
+// WithActor задает автора изменений для журнала.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+
+	return actor
+}
+
+// WithRequestID задает id запроса, в котором выполняются изменения.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
@@ -0,0 +1,122 @@
+package domain
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Состояния черновика. Изменять можно только открытый черновик.
+const (
+	DraftOpen      = "open"
+	DraftPublished = "published"
+	DraftDiscarded = "discarded"
+)
+
+// Виды изменений в черновике. Payload изменения зависит от вида.
+const (
+	DraftWaypointCreate = "waypoint_create" // Waypoint
+	DraftWaypointUpdate = "waypoint_update" // Waypoint
+	DraftWaypointDelete = "waypoint_delete" // без данных
+
+	DraftRouteCreate = "route_create" // Route и WaypointIds
+	DraftRouteUpdate = "route_update" // RouteUpdate
+	DraftRouteDelete = "route_delete" // без данных
+
+	// Изменения вариантов маршрута. EntityID - маршрут
+	DraftPatternCreate = "pattern_create" // RoutePattern и WaypointIds
+	DraftPatternUpdate = "pattern_update" // RoutePattern (ID, RouteID) и RoutePatternUpdate
+	DraftPatternDelete = "pattern_delete" // RoutePattern (ID, RouteID)
+
+	DraftStopAttach   = "stop_attach"   // WaypointRoute
+	DraftStopDetach   = "stop_detach"   // WaypointRoute (RouteID, PatternID, WaypointID)
+	DraftStopMove     = "stop_move"     // WaypointRoute (и RouteNumber)
+	DraftStopRules    = "stop_rules"    // WaypointRoute (и PickupType, DropOffType)
+	DraftStopValidity = "stop_validity" // WaypointRoute (и Validity)
+)
+
+// Черновик - набор изменений сети, которые применяются вместе при публикации.
+type Draft struct {
+	ID          uuid.UUID
+	Name        string
+	Status      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	PublishedAt *time.Time
+
+	Changes []DraftChange // Заполняется только при получении черновика по id
+}
+
+// Изменение в черновике. Изменения применяются по возрастанию Seq.
+type DraftChange struct {
+	Seq       int
+	Op        string
+	EntityID  uuid.UUID // Остановка или маршрут, которые изменяются
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+type DraftsRepository interface {
+	List(ctx context.Context, status string) ([]Draft, error)
+	GetById(ctx context.Context, id uuid.UUID) (Draft, error)
+	Create(ctx context.Context, draft Draft) error
+	// AddChange добавляет изменение в конец открытого черновика и возвращает его номер.
+	AddChange(ctx context.Context, id uuid.UUID, change DraftChange) (int, error)
+	// RemoveChange удаляет изменение seq из открытого черновика. Номера остальных изменений не меняются.
+	RemoveChange(ctx context.Context, id uuid.UUID, seq int) error
+	// Lock блокирует открытый черновик до конца транзакции из контекста: изменения черновика добавляются по очереди.
+	Lock(ctx context.Context, id uuid.UUID) error
+	// SetStatus закрывает открытый черновик (published или discarded).
+	SetStatus(ctx context.Context, id uuid.UUID, status string) error
+
+	// InTx выполняет fn в транзакции: все запросы репозиториев с контекстом fn видят изменения друг друга.
+	// Если commit == false или fn вернула ошибку, транзакция откатывается. Внутри другой транзакции InTx
+	// создает точку сохранения. Если запросы fn не дождались блокировки строк, возвращается ErrUnavailable.
+	InTx(ctx context.Context, commit bool, fn func(ctx context.Context) error) error
+}
+
+type DraftsUsecase interface {
+	List(ctx context.Context, status string) ([]Draft, error)
+	GetById(ctx context.Context, id uuid.UUID) (Draft, error)
+	Create(ctx context.Context, name string) (Draft, error)
+
+	// Stage проверяет изменение поверх черновика и добавляет его в черновик.
+	Stage(ctx context.Context, id uuid.UUID, op string, entityID uuid.UUID, payload any) error
+	// RemoveChange удаляет изменение из черновика, например, если оно больше не применимо.
+	RemoveChange(ctx context.Context, id uuid.UUID, seq int) (Draft, error)
+	// Preview выполняет fn над сетью с примененным черновиком. Изменения не сохраняются.
+	Preview(ctx context.Context, id uuid.UUID, fn func(ctx context.Context) error) error
+	Validate(ctx context.Context, id uuid.UUID, params ValidationParams) (ValidationReport, error)
+
+	Publish(ctx context.Context, id uuid.UUID) (Draft, error)
+	Discard(ctx context.Context, id uuid.UUID) (Draft, error)
+}
+
+type draftKey struct{}
+
+// WithDraft направляет изменения сети в черновик id вместо применения.
+func WithDraft(ctx context.Context, id uuid.UUID) context.Context {
+	return context.WithValue(ctx, draftKey{}, id)
+}
+
+// DraftFromContext возвращает черновик, в который направляются изменения сети.
+func DraftFromContext(ctx context.Context) (uuid.UUID, bool) {
+	id, ok := ctx.Value(draftKey{}).(uuid.UUID)
+
+	return id, ok
+}
+
+func ValidDraftStatus(status string) bool {
+	switch status {
+	case DraftOpen:
+		return true
+	case DraftPublished:
+		return true
+	case DraftDiscarded:
+		return true
+	default:
+		return false
+	}
+}
@@ -10,4 +10,5 @@ var (
 	ErrBadRequest          = errors.New("bad request")
 	ErrInvalidParam        = errors.New("invalid param")
 	ErrInternalServerError = errors.New("internal server error")
+	ErrUnavailable         = errors.New("service unavailable") // Строки заняты параллельными изменениями, запрос можно повторить
 )
@@ -0,0 +1,8 @@
+package domain
+
+// Параметры страницы списка. Если задан Cursor, Offset не используется.
+type Page struct {
+	Limit  uint64
+	Offset uint64
+	Cursor string // Непрозрачный курсор из NextCursor предыдущей страницы
+}
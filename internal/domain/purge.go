@@ -0,0 +1,17 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Результат очистки: сколько удаленных остановок и маршрутов удалено окончательно.
+type PurgeResult struct {
+	Waypoints int64
+	Routes    int64
+}
+
+type PurgeUsecase interface {
+	// Purge окончательно удаляет остановки и маршруты, удаленные раньше before. Восстановить их после этого нельзя.
+	Purge(ctx context.Context, before time.Time) (PurgeResult, error)
+}
@@ -0,0 +1,39 @@
+package domain
+
+import "context"
+
+// Справочники типов, на которые ссылаются маршруты.
+const (
+	VehicleTypes = "vehicle_types" // Типы транспорта (routes.vehicle_type)
+	RouteTypes   = "route_types"   // Типы маршрутов (routes.route_type)
+)
+
+// Запись справочника типов.
+type TypeEntry struct {
+	Code          string  // Значение в маршрутах, например "bus"
+	DisplayName   string  // Название для отображения
+	IconKey       string  // Ключ иконки на клиенте
+	Color         string  // Цвет в формате #RRGGBB
+	DefaultSpeed  float64 // Средняя скорость по умолчанию, км/ч
+	GTFSRouteType *int    // Значение route_type в GTFS
+}
+
+// Репозиторий одного справочника (VehicleTypes или RouteTypes).
+type TypesRepository interface {
+	List(ctx context.Context) ([]TypeEntry, error)
+	Get(ctx context.Context, code string) (TypeEntry, error)
+
+	Create(ctx context.Context, entry TypeEntry) error
+	Update(ctx context.Context, entry TypeEntry) error
+	// Delete удаляет запись. Запись, которая используется маршрутами, удалить нельзя (ErrConflict).
+	Delete(ctx context.Context, code string) error
+}
+
+type TypesUsecase interface {
+	List(ctx context.Context) ([]TypeEntry, error)
+	Get(ctx context.Context, code string) (TypeEntry, error)
+
+	Create(ctx context.Context, entry TypeEntry) error
+	Update(ctx context.Context, entry TypeEntry) (TypeEntry, error)
+	Delete(ctx context.Context, code string) error
+}
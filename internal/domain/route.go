@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -14,53 +15,241 @@ type Route struct {
 	Price       int    // Цена проезда на маршруте
 	VehicleType string // Тип транспорта
 	RouteType   string // Тип маршрута (внутригородской, межгородской)
+	IsLoop      bool   // Кольцевой маршрут: после последней остановки транспорт едет к первой
+
+	// Оформление маршрута
+	ShortName   string // Короткое название ("44"). По умолчанию совпадает с Name
+	LongName    string // Полное название. По умолчанию "первая остановка – последняя остановка" направления
+	Color       string // Цвет фона в формате #RRGGBB
+	TextColor   string // Цвет текста в формате #RRGGBB
+	Description string
+	URL         string
+
+	Validity
+}
+
+// Фильтр и сортировка списка маршрутов. Пустые поля не участвуют в фильтрации.
+type RouteFilter struct {
+	VehicleType string
+	RouteType   string
+	NamePrefix  string
+	PriceMin    *int
+	PriceMax    *int
+	WaypointID  *uuid.UUID // Только маршруты, проходящие через остановку
+
+	SortBy   string // RouteSortName или RouteSortPrice
+	SortDesc bool
+}
+
+const (
+	RouteSortName  = "name"  // Натуральный порядок: "9" < "44" < "44а"
+	RouteSortPrice = "price" // По цене, затем по названию
+)
+
+// Страница списка маршрутов.
+type RoutesPage struct {
+	Routes     []Route
+	NextCursor string // Пустой, если страница последняя
+	Total      int    // Общее количество маршрутов с учетом фильтра
+}
+
+// Частичное обновление маршрута. nil поля не изменяются.
+type RouteUpdate struct {
+	Name        *string
+	Price       *int
+	VehicleType *string
+	RouteType   *string
+	IsLoop      *bool
+
+	ShortName   *string // Пустая строка - название по умолчанию
+	LongName    *string // Пустая строка - название по умолчанию
+	Color       *string
+	TextColor   *string
+	Description *string
+	URL         *string
+
+	ValidFrom *time.Time // Нулевое время - без ограничения
+	ValidTo   *time.Time // Нулевое время - без ограничения
+}
+
+// Маршрут, по которому можно доехать между двумя остановками.
+type CommonRoute struct {
+	Route
+	PatternID uuid.UUID  // Вариант маршрута, по которому проходит участок
+	Stops     int        // Количество перегонов от начальной до конечной остановки
+	Segment   []Waypoint // Остановки участка по порядку, включая начальную и конечную
+}
+
+// Сгенерированное обратное направление маршрута.
+type ReversedRoute struct {
+	Route     Route
+	Waypoints []Waypoint // Подобранные остановки обратного направления по порядку
+	Unmatched []Waypoint // Остановки прямого направления, для которых не нашлось пары напротив
+}
+
+// Направление маршрута рядом с точкой и его ближайшая к точке остановка.
+type NearRouteDirection struct {
+	Route    Route
+	Waypoint Waypoint // Ближайшая остановка направления
+	Distance float64  // Расстояние до остановки в метрах
+}
+
+// Маршрут рядом с точкой. Оба направления маршрута (с одинаковым названием) объединены.
+type NearRoute struct {
+	Name       string
+	Distance   float64 // Расстояние до ближайшей остановки среди всех направлений в метрах
+	Directions []NearRouteDirection
 }
 
+// Вариант трассы направления маршрута: укороченный рейс, экспресс и т.д.
+// У каждого направления ровно один основной вариант, по которому считается Route.Length.
+type RoutePattern struct {
+	ID        uuid.UUID
+	RouteID   uuid.UUID
+	Name      string
+	IsDefault bool
+	Length    int // Количество остановок варианта
+}
+
+// Название основного варианта, который создается вместе с маршрутом.
+const DefaultPatternName = "default"
+
+// Изменение варианта маршрута. nil поля не изменяются.
+type RoutePatternUpdate struct {
+	Name      *string
+	IsDefault *bool // Только true: основной вариант нельзя снять, можно лишь назначить другой
+}
+
+// Правила посадки/высадки на остановке варианта маршрута.
+const (
+	StopRuleRegular   = "regular"    // Обычная посадка/высадка
+	StopRuleNone      = "none"       // Посадка/высадка невозможна
+	StopRuleOnRequest = "on_request" // По требованию
+)
+
 type WaypointRoute struct {
 	RouteID     uuid.UUID
+	PatternID   uuid.UUID // uuid.Nil - основной вариант маршрута
 	WaypointID  uuid.UUID
 	RouteName   string
 	RouteKind   int
 	RouteNumber int
+	PickupType  string // Посадка: regular, none, on_request
+	DropOffType string // Высадка: regular, none, on_request
+
+	Validity
 }
 
 type RoutesRepository interface {
-	List(ctx context.Context, limit, offset uint64) ([]Route, error)
+	List(ctx context.Context, page Page, filter RouteFilter) (RoutesPage, error)
 	GetById(ctx context.Context, id uuid.UUID) (Route, error)
 
 	Create(ctx context.Context, route Route, waypointIds []uuid.UUID) error
+	Update(ctx context.Context, id uuid.UUID, update RouteUpdate) error
+	// Delete помечает маршрут удаленным вместе с его остановками. Удаленные маршруты не видны при чтении.
 	Delete(ctx context.Context, id uuid.UUID) error
+	// Restore восстанавливает удаленный маршрут вместе с остановками, удаленными вместе с ним.
+	Restore(ctx context.Context, id uuid.UUID) error
+	// Purge окончательно удаляет маршруты, удаленные раньше before, и возвращает их количество.
+	Purge(ctx context.Context, before time.Time) (int64, error)
 
 	GetByIds(ctx context.Context, id ...uuid.UUID) ([]Route, error)
-	RouteWaypoints(ctx context.Context, rID uuid.UUID, rKind int) ([]Waypoint, error)
+	// RouteWaypoints возвращает остановки варианта pID по порядку. uuid.Nil - основной вариант.
+	RouteWaypoints(ctx context.Context, rID, pID uuid.UUID) ([]Waypoint, error)
+	// Near возвращает направления маршрутов с остановкой в радиусе radius метров, по возрастанию расстояния.
+	Near(ctx context.Context, latitude, longitude, radius float64) ([]NearRouteDirection, error)
+
+	// Изменение списка остановок варианта маршрута (uuid.Nil - основной вариант).
+	// Порядковые номера остальных остановок пересчитываются.
+	AttachWaypoint(ctx context.Context, wr WaypointRoute) error
+	DetachWaypoint(ctx context.Context, rID, pID, wID uuid.UUID) error
+	MoveWaypoint(ctx context.Context, rID, pID, wID uuid.UUID, routeNumber int) error
+	// SetStopRules изменяет правила посадки/высадки на остановке варианта маршрута.
+	SetStopRules(ctx context.Context, rID, pID, wID uuid.UUID, pickupType, dropOffType string) error
+	// SetStopValidity изменяет период действия остановки в варианте маршрута.
+	SetStopValidity(ctx context.Context, rID, pID, wID uuid.UUID, validity Validity) error
+
+	// Варианты трассы маршрута. Основной вариант создается вместе с маршрутом и не удаляется.
+	Patterns(ctx context.Context, rID uuid.UUID) ([]RoutePattern, error)
+	GetPattern(ctx context.Context, rID, pID uuid.UUID) (RoutePattern, error)
+	CreatePattern(ctx context.Context, pattern RoutePattern, waypointIds []uuid.UUID) error
+	UpdatePattern(ctx context.Context, rID, pID uuid.UUID, update RoutePatternUpdate) error
+	DeletePattern(ctx context.Context, rID, pID uuid.UUID) error
+
+	// Переводы названий. Translations возвращает названия на языке lang по идентификаторам маршрутов.
+	Translations(ctx context.Context, lang string, ids ...uuid.UUID) (map[uuid.UUID]string, error)
+	ListTranslations(ctx context.Context, id uuid.UUID) ([]Translation, error)
+	SetTranslation(ctx context.Context, id uuid.UUID, translation Translation) error
+	DeleteTranslation(ctx context.Context, id uuid.UUID, lang string) error
 }
 
 type RoutesUsecase interface {
-	List(ctx context.Context, limit, offset uint64) ([]Route, error)
-	GetById(ctx context.Context, id uuid.UUID) (Route, []Waypoint, error)
+	List(ctx context.Context, page Page, filter RouteFilter) (RoutesPage, error)
+	// GetById возвращает маршрут и остановки варианта pID (uuid.Nil - основной вариант).
+	GetById(ctx context.Context, id, pID uuid.UUID) (Route, []Waypoint, error)
 
 	Create(ctx context.Context, route Route, waypointIds []uuid.UUID) error
+	Update(ctx context.Context, id uuid.UUID, update RouteUpdate) (Route, error)
 	Delete(ctx context.Context, id uuid.UUID) error
+	// Restore восстанавливает удаленный маршрут вместе с его остановками.
+	Restore(ctx context.Context, id uuid.UUID) (Route, error)
+
+	// History возвращает журнал изменений маршрута (новые первыми), включая изменения его списка остановок.
+	History(ctx context.Context, id uuid.UUID, page Page) (AuditPage, error)
+
+	ListPatterns(ctx context.Context, id uuid.UUID) ([]RoutePattern, error)
+	CreatePattern(ctx context.Context, pattern RoutePattern, waypointIds []uuid.UUID) (RoutePattern, error)
+	UpdatePattern(ctx context.Context, id, pID uuid.UUID, update RoutePatternUpdate) (RoutePattern, error)
+	DeletePattern(ctx context.Context, id, pID uuid.UUID) error
+
+	Near(ctx context.Context, latitude, longitude, radius float64) ([]NearRoute, error)
+
+	Reverse(ctx context.Context, id uuid.UUID, radius float64, dryRun bool) (ReversedRoute, error)
+
+	ListTranslations(ctx context.Context, id uuid.UUID) ([]Translation, error)
+	SetTranslation(ctx context.Context, id uuid.UUID, translation Translation) error
+	DeleteTranslation(ctx context.Context, id uuid.UUID, lang string) error
 }
 
-func ValidVehicleType(vt string) bool {
-	switch vt {
-	case "bus":
-		return true
-	case "trolleybus":
+// Противоположное направление маршрута (1 <-> 2).
+func OppositeRouteKind(kind int) (int, bool) {
+	switch kind {
+	case 1:
+		return 2, true
+	case 2:
+		return 1, true
+	default:
+		return 0, false
+	}
+}
+
+// DefaultRouteLongName возвращает полное название направления по умолчанию: "первая остановка – последняя остановка".
+func DefaultRouteLongName(waypoints []Waypoint) string {
+	if len(waypoints) == 0 {
+		return ""
+	}
+
+	return waypoints[0].Name + " – " + waypoints[len(waypoints)-1].Name
+}
+
+func ValidRouteSort(sort string) bool {
+	switch sort {
+	case RouteSortName:
 		return true
-	case "train":
+	case RouteSortPrice:
 		return true
 	default:
 		return false
 	}
 }
 
-func ValidRouteType(rt string) bool {
-	switch rt {
-	case "city":
+func ValidStopRule(rule string) bool {
+	switch rule {
+	case StopRuleRegular:
+		return true
+	case StopRuleNone:
 		return true
-	case "intercity":
+	case StopRuleOnRequest:
 		return true
 	default:
 		return false
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Станция объединяет остановки одного места: направления и платформы с общим названием.
+// При поиске маршрутов все остановки станции считаются одной точкой отправления или прибытия.
+type Station struct {
+	ID        uuid.UUID
+	Name      string
+	Latitude  float64 // Центр станции (среднее координат остановок)
+	Longitude float64
+}
+
+// Предложение объединить остановки в станцию, найденное кластеризацией.
+type StationProposal struct {
+	Name      string
+	Waypoints []Waypoint
+}
+
+// Параметры поиска похожих остановок рядом друг с другом.
+type SimilarityParams struct {
+	Radius         float64 // Максимальное расстояние между остановками в метрах
+	MinSimilarity  float64 // Минимальная схожесть названий (0..1, pg_trgm similarity)
+	WithoutStation bool    // Только остановки, еще не входящие в станцию
+}
+
+// Пара похожих остановок.
+type WaypointPair struct {
+	First      Waypoint
+	Second     Waypoint
+	Distance   float64 // Расстояние в метрах
+	Similarity float64 // Схожесть названий (0..1)
+}
+
+type StationsRepository interface {
+	List(ctx context.Context) ([]Station, error)
+	GetById(ctx context.Context, id uuid.UUID) (Station, error)
+	Create(ctx context.Context, station Station, waypointIds []uuid.UUID) error
+	Update(ctx context.Context, station Station) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	Members(ctx context.Context, id uuid.UUID) ([]Waypoint, error)
+	AddMember(ctx context.Context, id, wID uuid.UUID) error
+	RemoveMember(ctx context.Context, id, wID uuid.UUID) error
+
+	// SiblingIds возвращает идентификаторы всех остановок станции, в которую входит wID (включая wID).
+	// Если остановка не входит в станцию, возвращается только wID.
+	SiblingIds(ctx context.Context, wID uuid.UUID) ([]uuid.UUID, error)
+}
+
+type StationsUsecase interface {
+	List(ctx context.Context) ([]Station, error)
+	GetById(ctx context.Context, id uuid.UUID) (Station, []Waypoint, error)
+	Create(ctx context.Context, station Station, waypointIds []uuid.UUID) (Station, error)
+	Update(ctx context.Context, station Station) error
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	AddMember(ctx context.Context, id, wID uuid.UUID) error
+	RemoveMember(ctx context.Context, id, wID uuid.UUID) error
+
+	// Propose группирует похожие остановки рядом друг с другом в предложения станций.
+	Propose(ctx context.Context, params SimilarityParams) ([]StationProposal, error)
+}
@@ -0,0 +1,7 @@
+package domain
+
+// Перевод названия остановки или маршрута.
+type Translation struct {
+	Lang string // Основной подтег языка (en, de, ...)
+	Name string
+}
@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/google/uuid"
+)
+
+// Проверки качества данных сети.
+const (
+	CheckRouteLength      = "route_length"      // Route.Length не совпадает с количеством остановок
+	CheckRouteNumbering   = "route_numbering"   // Пропуски или повторы route_number
+	CheckStopGap          = "stop_gap"          // Соседние остановки маршрута слишком далеко друг от друга
+	CheckIdenticalStops   = "identical_stops"   // Соседние остановки маршрута совпадают
+	CheckOrphanStop       = "orphan_stop"       // Остановка без маршрутов
+	CheckMissingDirection = "missing_direction" // У маршрута есть только одно из направлений 1 и 2
+)
+
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Найденная проблема в данных сети.
+type NetworkIssue struct {
+	Check      string
+	Severity   string
+	Message    string
+	RouteID    *uuid.UUID
+	WaypointID *uuid.UUID
+}
+
+// Расстояние между соседними остановками по умолчанию, после которого выдается предупреждение (в метрах).
+const DefaultMaxStopGap = 3000
+
+// Параметры проверки сети.
+type ValidationParams struct {
+	MaxStopGap float64 // Максимальное расстояние между соседними остановками маршрута в метрах
+}
+
+// Результат проверки сети.
+type ValidationReport struct {
+	Issues []NetworkIssue
+	Errors int
+	Warns  int
+}
+
+type ValidationRepository interface {
+	LengthMismatches(ctx context.Context) ([]NetworkIssue, error)
+	NumberingIssues(ctx context.Context) ([]NetworkIssue, error)
+	StopGaps(ctx context.Context, maxGap float64) ([]NetworkIssue, error)
+	OrphanStops(ctx context.Context) ([]NetworkIssue, error)
+	MissingDirections(ctx context.Context) ([]NetworkIssue, error)
+}
+
+type ValidationUsecase interface {
+	Validate(ctx context.Context, params ValidationParams) (ValidationReport, error)
+}
+
+// WriteText выводит отчет в человекочитаемом виде.
+func (r ValidationReport) WriteText(w io.Writer) error {
+	for _, issue := range r.Issues {
+		line := fmt.Sprintf("[%s] %s: %s", issue.Severity, issue.Check, issue.Message)
+
+		if issue.RouteID != nil {
+			line += fmt.Sprintf(" (route %s)", issue.RouteID)
+		}
+
+		if issue.WaypointID != nil {
+			line += fmt.Sprintf(" (waypoint %s)", issue.WaypointID)
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d errors, %d warnings\n", r.Errors, r.Warns)
+
+	return err
+}
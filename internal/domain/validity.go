@@ -0,0 +1,48 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// Формат дат начала и окончания действия.
+const DateLayout = "2006-01-02"
+
+// Период действия остановки, маршрута или остановки в маршруте. nil - без ограничения.
+// Обе границы включаются.
+type Validity struct {
+	ValidFrom *time.Time
+	ValidTo   *time.Time
+}
+
+// ValidAt сообщает, действует ли запись на дату at.
+func (v Validity) ValidAt(at time.Time) bool {
+	if v.ValidFrom != nil && at.Before(*v.ValidFrom) {
+		return false
+	}
+
+	if v.ValidTo != nil && at.After(*v.ValidTo) {
+		return false
+	}
+
+	return true
+}
+
+type validAtKey struct{}
+
+// WithValidAt задает дату, на которую читается сеть: записи, не действующие на эту дату, не возвращаются.
+func WithValidAt(ctx context.Context, at time.Time) context.Context {
+	return context.WithValue(ctx, validAtKey{}, Date(at))
+}
+
+// ValidAtFromContext возвращает дату, на которую читается сеть. Если дата не задана, возвращаются все записи.
+func ValidAtFromContext(ctx context.Context) (time.Time, bool) {
+	at, ok := ctx.Value(validAtKey{}).(time.Time)
+
+	return at, ok
+}
+
+// Date отбрасывает время суток.
+func Date(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
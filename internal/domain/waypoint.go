@@ -2,6 +2,7 @@ package domain
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -11,38 +12,207 @@ type Waypoint struct {
 	Name      string
 	Latitude  float64
 	Longitude float64
+
+	// Подробная информация об остановке. nil означает, что информация неизвестна.
+	WheelchairBoarding string // Доступность для колясок: unknown, accessible, not_accessible
+	Shelter            *bool  // Навес
+	Bench              *bool  // Скамейка
+	Lighting           *bool  // Освещение
+	TactilePaving      *bool  // Тактильная плитка
+	PlatformCode       string // Код платформы/остановки (например, "A" или "2")
+	Description        string // Произвольное описание
+
+	Validity
+}
+
+const (
+	WheelchairBoardingUnknown       = "unknown"
+	WheelchairBoardingAccessible    = "accessible"
+	WheelchairBoardingNotAccessible = "not_accessible"
+)
+
+// Фильтр списка точек. Пустые поля не участвуют в фильтрации.
+type WaypointFilter struct {
+	WheelchairBoarding string
+	Shelter            *bool
+	Bench              *bool
+	Lighting           *bool
+	TactilePaving      *bool
+	PlatformCode       string
+	BBox               *BBox // Только точки внутри прямоугольника
+}
+
+// Прямоугольная область в градусах (SRID 4326), например видимая часть карты.
+type BBox struct {
+	MinLon float64
+	MinLat float64
+	MaxLon float64
+	MaxLat float64
+}
+
+// Полигон в терминах GeoJSON: кольца из точек [долгота, широта], первое кольцо внешнее, остальные - отверстия.
+type Polygon [][][2]float64
+
+// Область из одного или нескольких полигонов (район, тарифная зона).
+type Area []Polygon
+
+// Страница списка точек.
+type WaypointsPage struct {
+	Waypoints  []Waypoint
+	NextCursor string // Пустой, если страница последняя
+	Total      int    // Общее количество точек с учетом фильтра
+}
+
+// Параметры нечеткого поиска точек по названию.
+type WaypointSearch struct {
+	Query     string
+	Latitude  *float64 // Если заданы координаты, ближайшие точки поднимаются выше
+	Longitude *float64
+	Limit     uint64
+}
+
+// Найденная точка с оценкой совпадения.
+type WaypointMatch struct {
+	Waypoint
+	Score    float64  // Итоговая оценка (схожесть названия с учетом расстояния)
+	Distance *float64 // Расстояние до заданных координат в метрах
+}
+
+// Параметры поиска ближайших точек.
+type NearestSearch struct {
+	Latitude  float64
+	Longitude float64
+	Amount    int
+	Radius    *float64 // Если задан, только точки в радиусе (в метрах)
+
+	// Фильтры по маршрутам, проходящим через точку. Пустые поля не участвуют в фильтрации.
+	VehicleType string
+	RouteType   string
+	RouteID     *uuid.UUID
+	RouteKind   *int
+}
+
+// Точка с расстоянием и направлением от заданных координат.
+type NearbyWaypoint struct {
+	Waypoint
+	Distance float64 // Расстояние в метрах
+	Bearing  float64 // Азимут на точку в градусах (0 - север, по часовой стрелке)
+	Routes   []Route // Маршруты через точку, подходящие под фильтры поиска
+}
+
+// Режим пакетного создания точек.
+type BatchMode string
+
+const (
+	BatchModeAllOrNothing BatchMode = "all_or_nothing" // Любая ошибка отменяет всю пачку
+	BatchModeBestEffort   BatchMode = "best_effort"    // Конфликтующие точки пропускаются, остальные создаются
+)
+
+// Результат создания одной точки из пачки.
+type BatchResult struct {
+	ID      uuid.UUID
+	Created bool
+	Error   string
 }
 
 type CommonRoutes struct {
 	From   Waypoint
 	To     Waypoint
-	Routes []Route
+	Routes []CommonRoute
 }
 
 type WaypointsRepository interface {
-	List(ctx context.Context, limit, offset uint64) ([]Waypoint, error)
+	List(ctx context.Context, page Page, filter WaypointFilter) (WaypointsPage, error)
 	GetById(ctx context.Context, id uuid.UUID) (Waypoint, error)
-	GetOfNearest(ctx context.Context, amount int, latitude, longitude float64) ([]Waypoint, error)
+	GetOfNearest(ctx context.Context, search NearestSearch) ([]NearbyWaypoint, error)
+	GetOpposite(ctx context.Context, waypoint Waypoint, radius float64, exclude []uuid.UUID) (Waypoint, error)
+	Search(ctx context.Context, search WaypointSearch) ([]WaypointMatch, error)
+	ListWithin(ctx context.Context, area Area, filter WaypointFilter) ([]Waypoint, error)
+	SimilarPairs(ctx context.Context, params SimilarityParams) ([]WaypointPair, error)
 	Create(ctx context.Context, waypoint Waypoint) error
+	CreateMany(ctx context.Context, waypoints []Waypoint, mode BatchMode) ([]uuid.UUID, error)
 	Update(ctx context.Context, waypoint Waypoint) error
+	// Delete помечает точку удаленной вместе с ее остановками в маршрутах. Удаленные точки не видны при чтении.
+	// Следующие остановки маршрутов сдвигаются на освободившееся место.
 	Delete(ctx context.Context, id uuid.UUID) error
+	// Restore восстанавливает удаленную точку вместе с остановками, удаленными вместе с ней.
+	// Остановки возвращаются на прежние номера, следующие сдвигаются.
+	Restore(ctx context.Context, id uuid.UUID) error
+	// Purge окончательно удаляет точки, удаленные раньше before, и возвращает их количество.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+
+	// Объединение дубликатов. Объединенная остановка удаляется, ее id остается псевдонимом survivorID.
+	Merge(ctx context.Context, survivorID, mergedID uuid.UUID) error
+	ResolveAlias(ctx context.Context, id uuid.UUID) (uuid.UUID, error)
 
 	// TODO Вынести в отдельный интерфейс
 	ListRoutes(ctx context.Context, wID uuid.UUID) ([]WaypointRoute, error)
-	WaypointRoutes(ctx context.Context, wID uuid.UUID) ([]WaypointRoute, error)
+	WaypointRoutes(ctx context.Context, wIDs ...uuid.UUID) ([]WaypointRoute, error)
+
+	// Переводы названий. Translations возвращает названия на языке lang по идентификаторам точек.
+	Translations(ctx context.Context, lang string, ids ...uuid.UUID) (map[uuid.UUID]string, error)
+	ListTranslations(ctx context.Context, id uuid.UUID) ([]Translation, error)
+	SetTranslation(ctx context.Context, id uuid.UUID, translation Translation) error
+	DeleteTranslation(ctx context.Context, id uuid.UUID, lang string) error
 }
 
 type WaypointsUsecase interface {
-	List(ctx context.Context, limit, offset uint64) ([]Waypoint, error)
+	List(ctx context.Context, page Page, filter WaypointFilter) (WaypointsPage, error)
 	GetById(ctx context.Context, id uuid.UUID) (Waypoint, error)
-	GetOfNearest(ctx context.Context, amount int, latitude, longitude float64) ([]Waypoint, error)
+	GetOfNearest(ctx context.Context, search NearestSearch) ([]NearbyWaypoint, error)
+	Search(ctx context.Context, search WaypointSearch) ([]WaypointMatch, error)
+	ListWithin(ctx context.Context, area Area, filter WaypointFilter) ([]Waypoint, error)
 	Create(ctx context.Context, waypoint Waypoint) error
+	CreateMany(ctx context.Context, waypoints []Waypoint, mode BatchMode) ([]BatchResult, error)
 	Update(ctx context.Context, waypoint Waypoint) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	// Restore восстанавливает удаленную остановку вместе с ее местами в маршрутах.
+	Restore(ctx context.Context, id uuid.UUID) (Waypoint, error)
+
+	// History возвращает журнал изменений остановки (новые первыми), включая изменения маршрутов, затронувшие ее.
+	History(ctx context.Context, id uuid.UUID, page Page) (AuditPage, error)
+
+	// FindDuplicates ищет пары остановок с одинаковыми или похожими названиями рядом друг с другом.
+	FindDuplicates(ctx context.Context, params SimilarityParams) ([]WaypointPair, error)
+	Merge(ctx context.Context, survivorID, mergedID uuid.UUID) error
 
 	CollectRoutes(ctx context.Context, amount int, lat1, long1, lat2, long2 float64) ([]CommonRoutes, error)
 
 	// TODO Вынести в отдельный интерфейс
 	ListRoutes(ctx context.Context, wID uuid.UUID) ([]WaypointRoute, error)
-	CommonRoutes(ctx context.Context, w1, w2 uuid.UUID) ([]Route, error)
+	CommonRoutes(ctx context.Context, w1, w2 uuid.UUID) ([]CommonRoute, error)
+
+	AttachRoute(ctx context.Context, wr WaypointRoute) error
+	DetachRoute(ctx context.Context, wID, rID, pID uuid.UUID) error
+	MoveRoute(ctx context.Context, wID, rID, pID uuid.UUID, routeNumber int) error
+	SetStopRules(ctx context.Context, wID, rID, pID uuid.UUID, pickupType, dropOffType string) error
+	SetStopValidity(ctx context.Context, wID, rID, pID uuid.UUID, validity Validity) error
+
+	ListTranslations(ctx context.Context, id uuid.UUID) ([]Translation, error)
+	SetTranslation(ctx context.Context, id uuid.UUID, translation Translation) error
+	DeleteTranslation(ctx context.Context, id uuid.UUID, lang string) error
+}
+
+func ValidBatchMode(mode string) bool {
+	switch BatchMode(mode) {
+	case BatchModeAllOrNothing:
+		return true
+	case BatchModeBestEffort:
+		return true
+	default:
+		return false
+	}
+}
+
+func ValidWheelchairBoarding(wb string) bool {
+	switch wb {
+	case WheelchairBoardingUnknown:
+		return true
+	case WheelchairBoardingAccessible:
+		return true
+	case WheelchairBoardingNotAccessible:
+		return true
+	default:
+		return false
+	}
 }
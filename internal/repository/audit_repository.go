@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	auditTable = "audit_log"
+)
+
+type auditRepo struct {
+	db conn
+}
+
+func NewAuditRepo(db *pgxpool.Pool) domain.AuditRepository {
+	return &auditRepo{db: conn{pool: db}}
+}
+
+// Курсор истории изменений: id последней возвращенной записи.
+type auditCursor struct {
+	ID int64 `json:"i"`
+}
+
+func scanAuditRecord(row pgx.CollectableRow) (domain.AuditRecord, error) {
+	var record domain.AuditRecord
+	err := row.Scan(&record.ID, &record.EntityType, &record.EntityID, &record.WaypointID, &record.Action,
+		&record.Actor, &record.RequestID, &record.Before, &record.After, &record.CreatedAt)
+
+	return record, err
+}
+
+// nullJSON сохраняет пустой снимок как NULL.
+func nullJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+
+	return data
+}
+
+func (r *auditRepo) Add(ctx context.Context, record domain.AuditRecord) error {
+	insertBuilder := sq.Insert(auditTable).
+		Columns("entity_type", "entity_id", "waypoint_id", "action", "actor", "request_id", "before", "after").
+		Values(record.EntityType, record.EntityID, record.WaypointID, record.Action,
+			record.Actor, record.RequestID, nullJSON(record.Before), nullJSON(record.After)).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+
+	return err
+}
+
+// auditCondition выбирает записи об объекте. Для остановки - также изменения маршрутов, затронувшие ее.
+func auditCondition(entityType string, id uuid.UUID) sq.Sqlizer {
+	condition := sq.Eq{"entity_type": entityType, "entity_id": id}
+
+	if entityType == domain.AuditWaypoint {
+		return sq.Or{condition, sq.Eq{"waypoint_id": id}}
+	}
+
+	return condition
+}
+
+func (r *auditRepo) History(ctx context.Context, entityType string, id uuid.UUID, page domain.Page) (domain.AuditPage, error) {
+	selectBuilder := sq.Select("id", "entity_type", "entity_id", "waypoint_id", "action",
+		"actor", "request_id", "before", "after", "created_at").
+		From(auditTable).
+		Where(auditCondition(entityType, id)).
+		OrderBy("id DESC").
+		Limit(page.Limit + 1).
+		PlaceholderFormat(sq.Dollar)
+
+	if page.Cursor != "" {
+		var cursor auditCursor
+		if err := decodeCursor(page.Cursor, &cursor); err != nil {
+
+			return domain.AuditPage{}, err
+		}
+
+		selectBuilder = selectBuilder.Where(sq.Lt{"id": cursor.ID})
+	} else {
+		selectBuilder = selectBuilder.Offset(page.Offset)
+	}
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return domain.AuditPage{}, err
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return domain.AuditPage{}, err
+	}
+
+	records, err := pgx.CollectRows(rows, scanAuditRecord)
+	if err != nil {
+
+		return domain.AuditPage{}, err
+	}
+
+	var result domain.AuditPage
+
+	if uint64(len(records)) > page.Limit {
+		records = records[:page.Limit]
+
+		if len(records) > 0 {
+			result.NextCursor, err = encodeCursor(auditCursor{ID: records[len(records)-1].ID})
+			if err != nil {
+
+				return domain.AuditPage{}, err
+			}
+		}
+	}
+
+	result.Records = records
+
+	query, args, err = sq.Select("COUNT(*)").
+		From(auditTable).
+		Where(auditCondition(entityType, id)).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return domain.AuditPage{}, err
+	}
+
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&result.Total); err != nil {
+
+		return domain.AuditPage{}, err
+	}
+
+	return result, nil
+}
+
+// Lock блокирует строку точки или маршрута до конца транзакции. Маршруты остановок точки блокируются раньше самой точки,
+// в том же порядке, что и при ее удалении (softDelete). Еще не созданный объект блокировать нечего.
+func (r *auditRepo) Lock(ctx context.Context, entityType string, id uuid.UUID) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		table := routesTable
+
+		if entityType == domain.AuditWaypoint {
+			if err := lockStopRoutes(ctx, tx, sq.Eq{waypointLink: id, "deleted_at": nil}); err != nil {
+				return err
+			}
+
+			table = waypointTable
+		}
+
+		query, args, err := sq.Select("id").
+			From(table).
+			Where(sq.Eq{"id": id}).
+			Suffix(lockSuffix(ctx, "FOR UPDATE")).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, query, args...)
+
+		return err
+	})
+}
+
+func (r *auditRepo) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return inTx(ctx, r.db, true, fn)
+}
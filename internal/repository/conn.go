@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type (
+	txKey           struct{}
+	rollbackOnlyKey struct{}
+)
+
+// withTx привязывает к контексту транзакцию: все запросы репозиториев с этим контекстом выполняются в ней.
+func withTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+func txFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+
+	return tx, ok
+}
+
+// Сколько запрос откатываемой транзакции ждет блокировку строки. Применение черновика в ней выполняет
+// настоящие UPDATE и INSERT, которые блокируют строки до отката, поэтому ожидание ограничено.
+const rollbackLockTimeout = "3s"
+
+// lockSuffix возвращает блокировку строк lock для SELECT или пустую строку в откатываемой транзакции
+// (предпросмотр и проверка черновика): ее изменения не сохраняются, поэтому явные блокировки перед чтением не нужны.
+// Строки, которые транзакция изменяет, все равно блокируются до ее отката.
+func lockSuffix(ctx context.Context, lock string) string {
+	if rollbackOnly, _ := ctx.Value(rollbackOnlyKey{}).(bool); rollbackOnly {
+		return ""
+	}
+
+	return lock
+}
+
+// conn выполняет запросы в транзакции из контекста (предпросмотр и публикация черновика), а без нее - в пуле соединений.
+// Begin внутри транзакции контекста создает точку сохранения.
+type conn struct {
+	pool *pgxpool.Pool
+}
+
+func (c conn) Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error) {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx.Exec(ctx, sql, args...)
+	}
+
+	return c.pool.Exec(ctx, sql, args...)
+}
+
+func (c conn) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx.Query(ctx, sql, args...)
+	}
+
+	return c.pool.Query(ctx, sql, args...)
+}
+
+func (c conn) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx.QueryRow(ctx, sql, args...)
+	}
+
+	return c.pool.QueryRow(ctx, sql, args...)
+}
+
+func (c conn) Begin(ctx context.Context) (pgx.Tx, error) {
+	if tx, ok := txFromContext(ctx); ok {
+		return tx.Begin(ctx)
+	}
+
+	return c.pool.Begin(ctx)
+}
+
+// inTx выполняет fn в транзакции, привязанной к контексту. Если commit == false или fn вернула ошибку, транзакция откатывается.
+// При commit == false запросы fn не блокируют строки явно (см. lockSuffix) и ждут чужие блокировки не дольше rollbackLockTimeout.
+// Если запрос не дождался блокировки или попал во взаимоблокировку, возвращается ErrUnavailable: запрос можно повторить.
+func inTx(ctx context.Context, db conn, commit bool, fn func(ctx context.Context) error) error {
+	tx, err := db.Begin(ctx)
+	if err != nil {
+
+		return err
+	}
+
+	if !commit {
+		ctx = context.WithValue(ctx, rollbackOnlyKey{}, true)
+
+		// SET LOCAL действует до конца транзакции или отката точки сохранения
+		if _, err := tx.Exec(ctx, "SET LOCAL lock_timeout = '"+rollbackLockTimeout+"'"); err != nil {
+
+			return errors.Join(err, tx.Rollback(ctx))
+		}
+	}
+
+	err = fn(withTx(ctx, tx))
+	if err == nil && commit {
+		return lockError(tx.Commit(ctx))
+	}
+
+	if rErr := tx.Rollback(ctx); rErr != nil {
+
+		return errors.Join(lockError(err), rErr)
+	}
+
+	return lockError(err)
+}
+
+// lockError заменяет ошибку ожидания блокировки (lock_timeout) и взаимоблокировки на ErrUnavailable.
+func lockError(err error) error {
+	var pgErr *pgconn.PgError
+
+	if errors.As(err, &pgErr) && (pgErr.Code == pgerrcode.LockNotAvailable || pgErr.Code == pgerrcode.DeadlockDetected) {
+		return fmt.Errorf("%w, %s", domain.ErrUnavailable, err)
+	}
+
+	return err
+}
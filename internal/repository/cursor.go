@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Курсор списка точек: последний возвращенный id.
+type waypointCursor struct {
+	ID uuid.UUID `json:"i"`
+}
+
+// Курсор списка маршрутов: ключ сортировки последнего возвращенного маршрута.
+// Сортировка входит в курсор, чтобы курсор нельзя было использовать с другой сортировкой.
+type routeCursor struct {
+	SortBy  string    `json:"o"`
+	Desc    bool      `json:"d"`
+	Price   int       `json:"p"`
+	Natural string    `json:"n"`
+	Name    string    `json:"s"`
+	Kind    int       `json:"k"`
+	ID      uuid.UUID `json:"i"`
+}
+
+func encodeCursor(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodeCursor(s string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("%w, invalid cursor: %s", domain.ErrBadRequest, err)
+	}
+
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("%w, invalid cursor: %s", domain.ErrBadRequest, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,304 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	draftsTable       = "drafts"
+	draftChangesTable = "draft_changes"
+)
+
+type draftsRepo struct {
+	db conn
+}
+
+func NewDraftsRepo(db *pgxpool.Pool) domain.DraftsRepository {
+	return &draftsRepo{db: conn{pool: db}}
+}
+
+func draftSelect() sq.SelectBuilder {
+	return sq.Select("id", "name", "status", "created_at", "updated_at", "published_at").
+		From(draftsTable).
+		PlaceholderFormat(sq.Dollar)
+}
+
+func scanDraft(row pgx.CollectableRow) (domain.Draft, error) {
+	var draft domain.Draft
+	err := row.Scan(&draft.ID, &draft.Name, &draft.Status, &draft.CreatedAt, &draft.UpdatedAt, &draft.PublishedAt)
+
+	return draft, err
+}
+
+func scanDraftChange(row pgx.CollectableRow) (domain.DraftChange, error) {
+	var change domain.DraftChange
+	err := row.Scan(&change.Seq, &change.Op, &change.EntityID, &change.Payload, &change.CreatedAt)
+
+	return change, err
+}
+
+// List возвращает черновики, новые первыми. Пустой status - черновики во всех состояниях.
+func (r *draftsRepo) List(ctx context.Context, status string) ([]domain.Draft, error) {
+	selectBuilder := draftSelect().OrderBy("created_at DESC")
+
+	if status != "" {
+		selectBuilder = selectBuilder.Where(sq.Eq{"status": status})
+	}
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanDraft)
+}
+
+// GetById возвращает черновик вместе с изменениями по порядку.
+func (r *draftsRepo) GetById(ctx context.Context, id uuid.UUID) (domain.Draft, error) {
+	query, args, err := draftSelect().Where(sq.Eq{"id": id}).ToSql()
+	if err != nil {
+
+		return domain.Draft{}, err
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return domain.Draft{}, err
+	}
+
+	draft, err := pgx.CollectExactlyOneRow(rows, scanDraft)
+	if err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Draft{}, fmt.Errorf("%w, draft %s", domain.ErrNotFound, id)
+		}
+
+		return domain.Draft{}, err
+	}
+
+	query, args, err = sq.Select("seq", "op", "entity_id", "payload", "created_at").
+		From(draftChangesTable).
+		Where(sq.Eq{"draft_id": id}).
+		OrderBy("seq").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return domain.Draft{}, err
+	}
+
+	rows, err = r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return domain.Draft{}, err
+	}
+
+	draft.Changes, err = pgx.CollectRows(rows, scanDraftChange)
+	if err != nil {
+
+		return domain.Draft{}, err
+	}
+
+	return draft, nil
+}
+
+func (r *draftsRepo) Create(ctx context.Context, draft domain.Draft) error {
+	insertBuilder := sq.Insert(draftsTable).
+		Columns("id", "name", "status").
+		Values(draft.ID, draft.Name, draft.Status).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	_, err = r.db.Exec(ctx, query, args...)
+
+	return err
+}
+
+// lockOpenDraft блокирует черновик до конца транзакции и проверяет, что он открыт.
+func lockOpenDraft(ctx context.Context, tx pgx.Tx, id uuid.UUID) error {
+	query, args, err := sq.Select("status").
+		From(draftsTable).
+		Where(sq.Eq{"id": id}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	var status string
+	if err := tx.QueryRow(ctx, query, args...).Scan(&status); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w, draft %s", domain.ErrNotFound, id)
+		}
+
+		return err
+	}
+
+	if status != domain.DraftOpen {
+		return fmt.Errorf("%w, draft %s is %s", domain.ErrConflict, id, status)
+	}
+
+	return nil
+}
+
+func (r *draftsRepo) AddChange(ctx context.Context, id uuid.UUID, change domain.DraftChange) (int, error) {
+	var seq int
+
+	err := runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		if err := lockOpenDraft(ctx, tx, id); err != nil {
+			return err
+		}
+
+		// Черновик заблокирован, поэтому следующий номер не займет параллельный запрос
+		query, args, err := sq.Select("COALESCE(MAX(seq), 0) + 1").
+			From(draftChangesTable).
+			Where(sq.Eq{"draft_id": id}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		if err := tx.QueryRow(ctx, query, args...).Scan(&seq); err != nil {
+
+			return err
+		}
+
+		insertBuilder := sq.Insert(draftChangesTable).
+			Columns("draft_id", "seq", "op", "entity_id", "payload").
+			Values(id, seq, change.Op, change.EntityID, change.Payload).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err = insertBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+
+			return err
+		}
+
+		query, args, err = sq.Update(draftsTable).
+			Set("updated_at", sq.Expr("NOW()")).
+			Where(sq.Eq{"id": id}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, query, args...)
+
+		return err
+	})
+
+	return seq, err
+}
+
+func (r *draftsRepo) Lock(ctx context.Context, id uuid.UUID) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		return lockOpenDraft(ctx, tx, id)
+	})
+}
+
+func (r *draftsRepo) RemoveChange(ctx context.Context, id uuid.UUID, seq int) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		if err := lockOpenDraft(ctx, tx, id); err != nil {
+			return err
+		}
+
+		// Номера остальных изменений не меняются: по ним изменения находят в черновике и в ошибках
+		query, args, err := sq.Delete(draftChangesTable).
+			Where(sq.Eq{"draft_id": id, "seq": seq}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w, draft %s change %d", domain.ErrNotFound, id, seq)
+		}
+
+		query, args, err = sq.Update(draftsTable).
+			Set("updated_at", sq.Expr("NOW()")).
+			Where(sq.Eq{"id": id}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, query, args...)
+
+		return err
+	})
+}
+
+func (r *draftsRepo) SetStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		if err := lockOpenDraft(ctx, tx, id); err != nil {
+			return err
+		}
+
+		updateBuilder := sq.Update(draftsTable).
+			Set("status", status).
+			Set("updated_at", sq.Expr("NOW()")).
+			Where(sq.Eq{"id": id}).
+			PlaceholderFormat(sq.Dollar)
+
+		if status == domain.DraftPublished {
+			updateBuilder = updateBuilder.Set("published_at", sq.Expr("NOW()"))
+		}
+
+		query, args, err := updateBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, query, args...)
+
+		return err
+	})
+}
+
+func (r *draftsRepo) InTx(ctx context.Context, commit bool, fn func(ctx context.Context) error) error {
+	return inTx(ctx, r.db, commit, fn)
+}
@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	routePatternsTable = "route_patterns"
+)
+
+// rowsQuerier - общее у пула и транзакции, чтобы выборки вариантов работали в обоих случаях.
+type rowsQuerier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// patternCondition выбирает вариант pID маршрута, а для uuid.Nil - основной вариант.
+func patternCondition(alias string, pID uuid.UUID) sq.Sqlizer {
+	if pID == uuid.Nil {
+		return sq.Eq{alias + ".is_default": true}
+	}
+
+	return sq.Eq{alias + ".id": pID}
+}
+
+// patternSelect выбирает варианты маршрутов вместе с количеством остановок.
+func patternSelect() sq.SelectBuilder {
+	return sq.Select("p.id", "p.route_id", "p.name", "p.is_default", "COUNT(wr.waypoint_id)").
+		From(routePatternsTable + " p").
+		LeftJoin(waypointRoutesTable + " wr ON wr.pattern_id = p.id AND wr.deleted_at IS NULL").
+		GroupBy("p.id").
+		PlaceholderFormat(sq.Dollar)
+}
+
+func scanPattern(row pgx.CollectableRow) (domain.RoutePattern, error) {
+	var pattern domain.RoutePattern
+	err := row.Scan(&pattern.ID, &pattern.RouteID, &pattern.Name, &pattern.IsDefault, &pattern.Length)
+
+	return pattern, err
+}
+
+func getPattern(ctx context.Context, db rowsQuerier, rID, pID uuid.UUID) (domain.RoutePattern, error) {
+	query, args, err := patternSelect().
+		Where(sq.Eq{"p.route_id": rID}).
+		Where(patternCondition("p", pID)).
+		ToSql()
+	if err != nil {
+
+		return domain.RoutePattern{}, err
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+
+		return domain.RoutePattern{}, err
+	}
+
+	pattern, err := pgx.CollectExactlyOneRow(rows, scanPattern)
+	if err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.RoutePattern{}, fmt.Errorf("%w, pattern %s of route %s", domain.ErrNotFound, pID, rID)
+		}
+
+		return domain.RoutePattern{}, err
+	}
+
+	return pattern, nil
+}
+
+func insertPattern(ctx context.Context, tx pgx.Tx, pattern domain.RoutePattern) error {
+	insertBuilder := sq.Insert(routePatternsTable).
+		Columns("id", "route_id", "name", "is_default").
+		Values(pattern.ID, pattern.RouteID, pattern.Name, pattern.IsDefault).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+		var pqErr *pgconn.PgError
+
+		if errors.As(err, &pqErr) {
+			if pqErr.Code == pgerrcode.UniqueViolation {
+				return fmt.Errorf("%w, %s", domain.ErrConflict, err)
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// insertPatternStops добавляет остановки waypointIds в вариант pID по порядку, начиная с 1.
+func insertPatternStops(ctx context.Context, tx pgx.Tx, route domain.Route, pID uuid.UUID, waypointIds []uuid.UUID) error {
+	if len(waypointIds) == 0 {
+		return nil
+	}
+
+	ok, err := lockVisibleWaypoints(ctx, tx, waypointIds...)
+	if err != nil {
+
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w, some waypoints do not exist", domain.ErrBadRequest)
+	}
+
+	for i, wID := range waypointIds {
+		insertBuilder := sq.Insert(waypointRoutesTable).
+			Columns("route_id", "pattern_id", "waypoint_id", "route_name", "route_number", "route_kind").
+			Values(route.ID, pID, wID, route.Name, i+1, route.RouteKind).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err := insertBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, query, args...)
+
+		if err != nil {
+			var pqErr *pgconn.PgError
+
+			if errors.As(err, &pqErr) {
+				if pqErr.Code == pgerrcode.UniqueViolation {
+					return fmt.Errorf("%w, %s", domain.ErrConflict, err)
+				}
+
+				if pqErr.Code == pgerrcode.ForeignKeyViolation {
+					return fmt.Errorf("%w, %s", domain.ErrBadRequest, err)
+				}
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Patterns возвращает варианты маршрута: сначала основной, затем остальные по названию.
+func (r *routesRepo) Patterns(ctx context.Context, rID uuid.UUID) ([]domain.RoutePattern, error) {
+	query, args, err := patternSelect().
+		Where(sq.Eq{"p.route_id": rID}).
+		OrderBy("p.is_default DESC", "p.name").
+		ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanPattern)
+}
+
+func (r *routesRepo) GetPattern(ctx context.Context, rID, pID uuid.UUID) (domain.RoutePattern, error) {
+	return getPattern(ctx, r.db, rID, pID)
+}
+
+// CreatePattern создает вариант маршрута. Если вариант основной, он заменяет прежний основной вариант.
+func (r *routesRepo) CreatePattern(ctx context.Context, pattern domain.RoutePattern, waypointIds []uuid.UUID) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		route, err := lockRoute(ctx, tx, pattern.RouteID)
+		if err != nil {
+
+			return err
+		}
+
+		if pattern.IsDefault {
+			if err := resetDefaultPattern(ctx, tx, route.ID); err != nil {
+
+				return err
+			}
+		}
+
+		if err := insertPattern(ctx, tx, pattern); err != nil {
+
+			return err
+		}
+
+		if err := insertPatternStops(ctx, tx, route, pattern.ID, waypointIds); err != nil {
+
+			return err
+		}
+
+		return updateRouteLength(ctx, tx, pattern, len(waypointIds))
+	})
+}
+
+func (r *routesRepo) UpdatePattern(ctx context.Context, rID, pID uuid.UUID, update domain.RoutePatternUpdate) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		_, pattern, count, err := lockPattern(ctx, tx, rID, pID)
+		if err != nil {
+
+			return err
+		}
+
+		updateBuilder := sq.Update(routePatternsTable).
+			Where(sq.Eq{"id": pattern.ID}).
+			PlaceholderFormat(sq.Dollar)
+
+		if update.Name != nil {
+			updateBuilder = updateBuilder.Set("name", *update.Name)
+		}
+
+		if update.IsDefault != nil && *update.IsDefault && !pattern.IsDefault {
+			if err := resetDefaultPattern(ctx, tx, rID); err != nil {
+
+				return err
+			}
+
+			updateBuilder = updateBuilder.Set("is_default", true)
+			pattern.IsDefault = true
+		} else if update.Name == nil {
+			return nil
+		}
+
+		query, args, err := updateBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			var pqErr *pgconn.PgError
+
+			if errors.As(err, &pqErr) {
+				if pqErr.Code == pgerrcode.UniqueViolation {
+					return fmt.Errorf("%w, %s", domain.ErrConflict, err)
+				}
+			}
+
+			return err
+		}
+
+		return updateRouteLength(ctx, tx, pattern, count)
+	})
+}
+
+// DeletePattern удаляет вариант маршрута вместе с его остановками. Основной вариант удалить нельзя.
+func (r *routesRepo) DeletePattern(ctx context.Context, rID, pID uuid.UUID) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		_, pattern, _, err := lockPattern(ctx, tx, rID, pID)
+		if err != nil {
+
+			return err
+		}
+
+		if pattern.IsDefault {
+			return fmt.Errorf("%w, pattern %s is the default pattern of route %s", domain.ErrConflict, pattern.ID, rID)
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM route_patterns WHERE id = $1", pattern.ID)
+
+		return err
+	})
+}
+
+// resetDefaultPattern снимает признак основного варианта у всех вариантов маршрута.
+func resetDefaultPattern(ctx context.Context, tx pgx.Tx, rID uuid.UUID) error {
+	_, err := tx.Exec(ctx, "UPDATE route_patterns SET is_default = FALSE WHERE route_id = $1 AND is_default", rID)
+
+	return err
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/dzhordano/maps-api/internal/domain"
@@ -18,51 +20,300 @@ const (
 	routesTable = "routes"
 )
 
+// routeColumns возвращает колонки маршрута для routeDest с псевдонимом таблицы alias.
+// Пустое короткое название заменяется названием маршрута, пустое полное - заполняет fillDefaultLongNames.
+func routeColumns(alias string) []string {
+	a := alias + "."
+
+	return []string{
+		a + "id", a + "name", a + "route_kind", a + "length", a + "price", a + "vehicle_type", a + "route_type", a + "is_loop",
+		fmt.Sprintf("COALESCE(NULLIF(%sshort_name, ''), %sname)", a, a),
+		fmt.Sprintf("COALESCE(%slong_name, '')", a),
+		a + "color", a + "text_color", a + "description", a + "url",
+		a + "valid_from", a + "valid_to",
+	}
+}
+
+// fillDefaultLongNames подставляет полное название по умолчанию ("первая остановка – последняя остановка"
+// основного варианта) маршрутам без своего полного названия. Названия всех маршрутов считаются одним запросом.
+func fillDefaultLongNames(ctx context.Context, db conn, routes ...*domain.Route) error {
+	var ids []uuid.UUID
+	for _, route := range routes {
+		if route.LongName == "" {
+			ids = append(ids, route.ID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := db.Query(ctx, `
+    SELECT wr.route_id,
+      (array_agg(w.name ORDER BY wr.route_number))[1] || ' – ' || (array_agg(w.name ORDER BY wr.route_number DESC))[1]
+    FROM waypoint_routes wr
+    JOIN route_patterns p ON p.id = wr.pattern_id AND p.is_default
+    JOIN waypoints w ON w.id = wr.waypoint_id
+    WHERE wr.route_id = ANY($1) AND wr.deleted_at IS NULL
+    GROUP BY wr.route_id;
+	`, ids)
+	if err != nil {
+
+		return err
+	}
+
+	defer rows.Close()
+
+	names := make(map[uuid.UUID]string, len(ids))
+	for rows.Next() {
+		var (
+			id   uuid.UUID
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+
+			return err
+		}
+		names[id] = name
+	}
+
+	if err := rows.Err(); err != nil {
+
+		return err
+	}
+
+	for _, route := range routes {
+		if route.LongName == "" {
+			route.LongName = names[route.ID]
+		}
+	}
+
+	return nil
+}
+
+// routeRefs возвращает указатели на маршруты routes для fillDefaultLongNames.
+func routeRefs(routes []domain.Route) []*domain.Route {
+	refs := make([]*domain.Route, len(routes))
+	for i := range routes {
+		refs[i] = &routes[i]
+	}
+
+	return refs
+}
+
+func routeDest(route *domain.Route) []any {
+	return []any{
+		&route.ID, &route.Name, &route.RouteKind, &route.Length, &route.Price, &route.VehicleType, &route.RouteType, &route.IsLoop,
+		&route.ShortName, &route.LongName, &route.Color, &route.TextColor, &route.Description, &route.URL,
+		&route.ValidFrom, &route.ValidTo,
+	}
+}
+
 type routesRepo struct {
-	db *pgxpool.Pool
+	db conn
 }
 
 func NewRoutesRepo(db *pgxpool.Pool) domain.RoutesRepository {
-	return &routesRepo{db: db}
+	return &routesRepo{db: conn{pool: db}}
 }
 
-func (r *routesRepo) List(ctx context.Context, limit, offset uint64) ([]domain.Route, error) {
-	selectBuilder := sq.Select("id", "name", "route_kind", "length", "price", "vehicle_type", "route_type").
+// List возвращает страницу маршрутов в порядке filter.SortBy.
+// С курсором используется keyset-пагинация по полному ключу сортировки, иначе limit/offset.
+func (r *routesRepo) List(ctx context.Context, page domain.Page, filter domain.RouteFilter) (domain.RoutesPage, error) {
+	selectBuilder := sq.Select(routeColumns(routesTable)...).
+		Column(routeNaturalKey + "::TEXT").
 		From(routesTable).
-		Limit(limit).
-		Offset(offset).
+		Where(routeFilterToSql(filter)).
+		Where(visible(ctx, routesTable)).
+		OrderBy(routeOrderBy(filter)...).
+		Limit(page.Limit + 1).
 		PlaceholderFormat(sq.Dollar)
 
+	if page.Cursor != "" {
+		var cursor routeCursor
+		if err := decodeCursor(page.Cursor, &cursor); err != nil {
+
+			return domain.RoutesPage{}, err
+		}
+
+		if cursor.SortBy != filter.SortBy || cursor.Desc != filter.SortDesc {
+			return domain.RoutesPage{}, fmt.Errorf("%w, cursor was issued for another sort order", domain.ErrBadRequest)
+		}
+
+		selectBuilder = selectBuilder.Where(routeCursorToSql(cursor))
+	} else {
+		selectBuilder = selectBuilder.Offset(page.Offset)
+	}
+
 	query, args, err := selectBuilder.ToSql()
 	if err != nil {
 
-		return nil, err
+		return domain.RoutesPage{}, err
 	}
 
-	var routes []domain.Route
+	var (
+		routes   []domain.Route
+		naturals []string
+	)
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 
-		return nil, err
+		return domain.RoutesPage{}, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var route domain.Route
-		if err := rows.Scan(&route.ID, &route.Name, &route.RouteKind, &route.Length, &route.Price, &route.VehicleType, &route.RouteType); err != nil {
+		var (
+			route   domain.Route
+			natural string
+		)
+		if err := rows.Scan(append(routeDest(&route), &natural)...); err != nil {
 
-			return nil, err
+			return domain.RoutesPage{}, err
 		}
 		routes = append(routes, route)
+		naturals = append(naturals, natural)
 	}
 
-	return routes, nil
+	if err := rows.Err(); err != nil {
+
+		return domain.RoutesPage{}, err
+	}
+
+	var result domain.RoutesPage
+
+	if uint64(len(routes)) > page.Limit {
+		routes = routes[:page.Limit]
+
+		if len(routes) > 0 {
+			last := routes[len(routes)-1]
+
+			result.NextCursor, err = encodeCursor(routeCursor{
+				SortBy:  filter.SortBy,
+				Desc:    filter.SortDesc,
+				Price:   last.Price,
+				Natural: naturals[len(routes)-1],
+				Name:    last.Name,
+				Kind:    last.RouteKind,
+				ID:      last.ID,
+			})
+			if err != nil {
+
+				return domain.RoutesPage{}, err
+			}
+		}
+	}
+
+	if err := fillDefaultLongNames(ctx, r.db, routeRefs(routes)...); err != nil {
+
+		return domain.RoutesPage{}, err
+	}
+
+	result.Routes = routes
+
+	countBuilder := sq.Select("COUNT(*)").
+		From(routesTable).
+		Where(routeFilterToSql(filter)).
+		Where(visible(ctx, routesTable)).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err = countBuilder.ToSql()
+	if err != nil {
+
+		return domain.RoutesPage{}, err
+	}
+
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&result.Total); err != nil {
+
+		return domain.RoutesPage{}, err
+	}
+
+	return result, nil
+}
+
+func routeFilterToSql(filter domain.RouteFilter) sq.And {
+	conditions := sq.And{}
+
+	if filter.VehicleType != "" {
+		conditions = append(conditions, sq.Eq{"vehicle_type": filter.VehicleType})
+	}
+
+	if filter.RouteType != "" {
+		conditions = append(conditions, sq.Eq{"route_type": filter.RouteType})
+	}
+
+	if filter.NamePrefix != "" {
+		conditions = append(conditions, sq.ILike{"name": escapeLike(filter.NamePrefix) + "%"})
+	}
+
+	if filter.PriceMin != nil {
+		conditions = append(conditions, sq.GtOrEq{"price": *filter.PriceMin})
+	}
+
+	if filter.PriceMax != nil {
+		conditions = append(conditions, sq.LtOrEq{"price": *filter.PriceMax})
+	}
+
+	if filter.WaypointID != nil {
+		conditions = append(conditions, sq.Expr(
+			"id IN (SELECT route_id FROM "+waypointRoutesTable+" WHERE waypoint_id = ? AND deleted_at IS NULL)", *filter.WaypointID,
+		))
+	}
+
+	return conditions
+}
+
+// Числовой префикс названия для натуральной сортировки ("9" < "44" < "44а").
+// Названия без числового префикса идут после остальных.
+const routeNaturalKey = "COALESCE(substring(name FROM '^[0-9]+')::NUMERIC, 'Infinity'::NUMERIC)"
+
+// routeOrderBy возвращает полный ключ сортировки маршрутов. Все части ключа сортируются в одном направлении,
+// чтобы по нему можно было строить keyset-курсор.
+func routeOrderBy(filter domain.RouteFilter) []string {
+	direction := "ASC"
+	if filter.SortDesc {
+		direction = "DESC"
+	}
+
+	byName := []string{
+		routeNaturalKey + " " + direction,
+		"name " + direction,
+		"route_kind " + direction,
+		"id " + direction,
+	}
+
+	if filter.SortBy == domain.RouteSortPrice {
+		return append([]string{"price " + direction}, byName...)
+	}
+
+	return byName
+}
+
+// routeCursorToSql выбирает маршруты строго после курсора в порядке routeOrderBy.
+func routeCursorToSql(cursor routeCursor) sq.Sqlizer {
+	op := ">"
+	if cursor.Desc {
+		op = "<"
+	}
+
+	if cursor.SortBy == domain.RouteSortPrice {
+		return sq.Expr(
+			fmt.Sprintf("(price, %s, name, route_kind, id) %s (?, ?::NUMERIC, ?, ?, ?)", routeNaturalKey, op),
+			cursor.Price, cursor.Natural, cursor.Name, cursor.Kind, cursor.ID,
+		)
+	}
+
+	return sq.Expr(
+		fmt.Sprintf("(%s, name, route_kind, id) %s (?::NUMERIC, ?, ?, ?)", routeNaturalKey, op),
+		cursor.Natural, cursor.Name, cursor.Kind, cursor.ID,
+	)
 }
 
 func (r *routesRepo) GetById(ctx context.Context, id uuid.UUID) (domain.Route, error) {
-	selectBuilder := sq.Select("id", "name", "route_kind", "length", "price", "vehicle_type", "route_type").
+	selectBuilder := sq.Select(routeColumns(routesTable)...).
 		From(routesTable).
 		Where(sq.Eq{"id": id}).
+		Where(visible(ctx, routesTable)).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := selectBuilder.ToSql()
@@ -72,7 +323,7 @@ func (r *routesRepo) GetById(ctx context.Context, id uuid.UUID) (domain.Route, e
 	}
 
 	var route domain.Route
-	if err := r.db.QueryRow(ctx, query, args...).Scan(&route.ID, &route.Name, &route.RouteKind, &route.Length, &route.Price, &route.VehicleType, &route.RouteType); err != nil {
+	if err := r.db.QueryRow(ctx, query, args...).Scan(routeDest(&route)...); err != nil {
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Route{}, fmt.Errorf("%w, %s", domain.ErrNotFound, err)
@@ -81,13 +332,93 @@ func (r *routesRepo) GetById(ctx context.Context, id uuid.UUID) (domain.Route, e
 		return domain.Route{}, err
 	}
 
+	if err := fillDefaultLongNames(ctx, r.db, &route); err != nil {
+
+		return domain.Route{}, err
+	}
+
 	return route, nil
 }
 
+// Near ищет направления маршрутов, у которых есть остановка в радиусе radius метров.
+// Геометрия маршрутов не хранится, поэтому близость определяется только по остановкам.
+func (r *routesRepo) Near(ctx context.Context, latitude, longitude, radius float64) ([]domain.NearRouteDirection, error) {
+	query := fmt.Sprintf(`
+    SELECT %s,
+      n.waypoint_id, n.waypoint_name, n.latitude, n.longitude,
+      n.wheelchair_boarding, n.shelter, n.bench, n.lighting, n.tactile_paving, n.platform_code, n.description,
+      n.valid_from, n.valid_to, n.distance
+    FROM (
+      SELECT DISTINCT ON (wr.route_id)
+        wr.route_id,
+        w.id AS waypoint_id, w.name AS waypoint_name, w.latitude, w.longitude,
+        w.wheelchair_boarding, w.shelter, w.bench, w.lighting, w.tactile_paving, w.platform_code, w.description,
+        w.valid_from, w.valid_to,
+        ST_Distance(w.geom::geography, ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography) AS distance
+      FROM waypoint_routes wr
+      JOIN waypoints w ON w.id = wr.waypoint_id
+      WHERE ST_DWithin(
+        w.geom::geography,
+        ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography,
+        $3
+      )
+        AND `+visibleSql("w", 4)+`
+        AND `+visibleSql("wr", 4)+`
+      ORDER BY wr.route_id, distance
+    ) n
+    JOIN routes r ON r.id = n.route_id
+    WHERE `+visibleSql("r", 4)+`
+    ORDER BY n.distance, r.name, r.route_kind;
+	`, strings.Join(routeColumns("r"), ", "))
+
+	rows, err := r.db.Query(ctx, query, longitude, latitude, radius, validAtArg(ctx))
+	if err != nil {
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var directions []domain.NearRouteDirection
+	for rows.Next() {
+		var (
+			d  domain.NearRouteDirection
+			wp = &d.Waypoint
+		)
+		dest := append(routeDest(&d.Route),
+			&wp.ID, &wp.Name, &wp.Latitude, &wp.Longitude,
+			&wp.WheelchairBoarding, &wp.Shelter, &wp.Bench, &wp.Lighting, &wp.TactilePaving, &wp.PlatformCode, &wp.Description,
+			&wp.ValidFrom, &wp.ValidTo, &d.Distance,
+		)
+		if err := rows.Scan(dest...); err != nil {
+
+			return nil, err
+		}
+		directions = append(directions, d)
+	}
+
+	if err := rows.Err(); err != nil {
+
+		return nil, err
+	}
+
+	refs := make([]*domain.Route, len(directions))
+	for i := range directions {
+		refs[i] = &directions[i].Route
+	}
+
+	if err := fillDefaultLongNames(ctx, r.db, refs...); err != nil {
+
+		return nil, err
+	}
+
+	return directions, nil
+}
+
 func (r *routesRepo) GetByIds(ctx context.Context, id ...uuid.UUID) ([]domain.Route, error) {
-	selectBuilder := sq.Select("id", "name", "route_kind", "length", "price", "vehicle_type", "route_type").
+	selectBuilder := sq.Select(routeColumns(routesTable)...).
 		From(routesTable).
 		Where(sq.Eq{"id": id}).
+		Where(visible(ctx, routesTable)).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := selectBuilder.ToSql()
@@ -106,21 +437,33 @@ func (r *routesRepo) GetByIds(ctx context.Context, id ...uuid.UUID) ([]domain.Ro
 
 	for rows.Next() {
 		var route domain.Route
-		if err := rows.Scan(&route.ID, &route.Name, &route.RouteKind, &route.Length, &route.Price, &route.VehicleType, &route.RouteType); err != nil {
+		if err := rows.Scan(routeDest(&route)...); err != nil {
 
 			return nil, err
 		}
 		routes = append(routes, route)
 	}
 
+	if err := rows.Err(); err != nil {
+
+		return nil, err
+	}
+
+	if err := fillDefaultLongNames(ctx, r.db, routeRefs(routes)...); err != nil {
+
+		return nil, err
+	}
+
 	return routes, nil
 }
 
 func (r *routesRepo) Create(ctx context.Context, route domain.Route, waypointIds []uuid.UUID) (err error) {
 	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
 		insertBuilder := sq.Insert(routesTable).
-			Columns("id", "name", "route_kind", "length", "price", "vehicle_type", "route_type").
-			Values(route.ID, route.Name, route.RouteKind, route.Length, route.Price, route.VehicleType, route.RouteType).
+			Columns("id", "name", "route_kind", "length", "price", "vehicle_type", "route_type", "is_loop",
+				"short_name", "long_name", "color", "text_color", "description", "url", "valid_from", "valid_to").
+			Values(route.ID, route.Name, route.RouteKind, route.Length, route.Price, route.VehicleType, route.RouteType, route.IsLoop,
+				route.ShortName, route.LongName, route.Color, route.TextColor, route.Description, route.URL, route.ValidFrom, route.ValidTo).
 			PlaceholderFormat(sq.Dollar)
 
 		query, args, err := insertBuilder.ToSql()
@@ -143,70 +486,152 @@ func (r *routesRepo) Create(ctx context.Context, route domain.Route, waypointIds
 			return err
 		}
 
-		for i, wID := range waypointIds {
-			insertBuilder := sq.Insert(waypointRoutesTable).
-				Columns("route_id", "waypoint_id", "route_name", "route_number", "route_kind").
-				Values(route.ID, wID, route.Name, i+1, route.RouteKind).
-				PlaceholderFormat(sq.Dollar)
+		patternID, err := uuid.NewUUID()
+		if err != nil {
 
-			query, args, err := insertBuilder.ToSql()
-			if err != nil {
+			return err
+		}
+
+		pattern := domain.RoutePattern{ID: patternID, RouteID: route.ID, Name: domain.DefaultPatternName, IsDefault: true}
+		if err := insertPattern(ctx, tx, pattern); err != nil {
+
+			return err
+		}
+
+		return insertPatternStops(ctx, tx, route, patternID, waypointIds)
+	})
+}
+
+// Update обновляет маршрут и денормализованное имя маршрута в waypoint_routes.
+func (r *routesRepo) Update(ctx context.Context, id uuid.UUID, update domain.RouteUpdate) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		updateBuilder := sq.Update(routesTable).
+			Where(sq.Eq{"id": id, "deleted_at": nil}).
+			PlaceholderFormat(sq.Dollar)
+
+		if update.Name != nil {
+			updateBuilder = updateBuilder.Set("name", *update.Name)
+		}
+
+		if update.Price != nil {
+			updateBuilder = updateBuilder.Set("price", *update.Price)
+		}
+
+		if update.VehicleType != nil {
+			updateBuilder = updateBuilder.Set("vehicle_type", *update.VehicleType)
+		}
 
-				return err
+		if update.RouteType != nil {
+			updateBuilder = updateBuilder.Set("route_type", *update.RouteType)
+		}
+
+		if update.IsLoop != nil {
+			updateBuilder = updateBuilder.Set("is_loop", *update.IsLoop)
+		}
+
+		// Колонки перечислены срезом, а не map: порядок в SET и текст запроса не меняются от вызова к вызову
+		for _, field := range []struct {
+			column string
+			value  *time.Time
+		}{
+			{"valid_from", update.ValidFrom},
+			{"valid_to", update.ValidTo},
+		} {
+			if field.value != nil {
+				updateBuilder = updateBuilder.Set(field.column, dateOrNull(*field.value))
+			}
+		}
+
+		for _, field := range []struct {
+			column string
+			value  *string
+		}{
+			{"short_name", update.ShortName},
+			{"long_name", update.LongName},
+			{"color", update.Color},
+			{"text_color", update.TextColor},
+			{"description", update.Description},
+			{"url", update.URL},
+		} {
+			if field.value != nil {
+				updateBuilder = updateBuilder.Set(field.column, *field.value)
 			}
+		}
 
-			_, err = tx.Exec(ctx, query, args...)
+		query, args, err := updateBuilder.ToSql()
+		if err != nil {
 
-			if err != nil {
-				var pqErr *pgconn.PgError
+			return err
+		}
 
-				if errors.As(err, &pqErr) {
-					if pqErr.Code == pgerrcode.UniqueViolation {
-						return fmt.Errorf("%w, %s", domain.ErrConflict, err)
-					}
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+			var pqErr *pgconn.PgError
 
-					if pqErr.Code == pgerrcode.ForeignKeyViolation {
-						return fmt.Errorf("%w, %s", domain.ErrBadRequest, err)
-					}
+			if errors.As(err, &pqErr) {
+				if pqErr.Code == pgerrcode.UniqueViolation {
+					return fmt.Errorf("%w, %s", domain.ErrConflict, err)
 				}
 
-				return err
+				if pqErr.Code == pgerrcode.CheckViolation {
+					return fmt.Errorf("%w, %s", domain.ErrBadRequest, err)
+				}
 			}
-		}
 
-		return nil
-	})
-}
+			return err
+		}
 
-func (r *routesRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	deleteBuilder := sq.Delete(routesTable).
-		Where(sq.Eq{"id": id}).
-		PlaceholderFormat(sq.Dollar)
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w, route %s", domain.ErrNotFound, id)
+		}
 
-	query, args, err := deleteBuilder.ToSql()
-	if err != nil {
+		if update.Name == nil {
+			return nil
+		}
 
-		return err
-	}
+		// Имя обновляется и у скрытых остановок, чтобы после восстановления точек оно было актуальным
+		updateBuilder = sq.Update(waypointRoutesTable).
+			Set("route_name", *update.Name).
+			Where(sq.Eq{"route_id": id}).
+			PlaceholderFormat(sq.Dollar)
 
-	_, err = r.db.Exec(ctx, query, args...)
+		query, args, err = updateBuilder.ToSql()
+		if err != nil {
 
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%w, %s", domain.ErrNotFound, err)
+			return err
 		}
 
+		_, err = tx.Exec(ctx, query, args...)
+
 		return err
-	}
+	})
+}
 
-	return nil
+// Delete помечает маршрут удаленным вместе с его остановками. Восстановить его можно через Restore.
+func (r *routesRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	return softDelete(ctx, r.db, routesTable, routeLink, id)
 }
 
-func (r *routesRepo) RouteWaypoints(ctx context.Context, rID uuid.UUID, rKind int) ([]domain.Waypoint, error) {
-	selectBuilder := sq.Select("id", "name", "latitude", "longitude").
-		From(waypointTable).
-		Join(waypointRoutesTable + " ON id = waypoint_id").
-		Where(sq.Eq{"route_id": rID, "route_kind": rKind}).
+// Restore восстанавливает удаленный маршрут вместе с остановками, удаленными вместе с ним.
+func (r *routesRepo) Restore(ctx context.Context, id uuid.UUID) error {
+	return restore(ctx, r.db, routesTable, routeLink, waypointTable, waypointLink, id, restoreRouteStops)
+}
+
+// Purge окончательно удаляет маршруты, удаленные раньше before.
+func (r *routesRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purge(ctx, r.db, routesTable, before)
+}
+
+func (r *routesRepo) RouteWaypoints(ctx context.Context, rID, pID uuid.UUID) ([]domain.Waypoint, error) {
+	selectBuilder := sq.Select(prefixedWaypointColumns("w")...).
+		From(waypointTable + " w").
+		Join(waypointRoutesTable + " wr ON wr.waypoint_id = w.id").
+		Join(routePatternsTable + " p ON p.id = wr.pattern_id").
+		Where(sq.Eq{"wr.route_id": rID}).
+		Where(patternCondition("p", pID)).
+		Where(visible(ctx, "wr")).
+		Where(visible(ctx, "w")).
+		OrderBy("wr.route_number").
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := selectBuilder.ToSql()
@@ -226,8 +651,8 @@ func (r *routesRepo) RouteWaypoints(ctx context.Context, rID uuid.UUID, rKind in
 	defer rows.Close()
 
 	for rows.Next() {
-		var waypoint domain.Waypoint
-		if err := rows.Scan(&waypoint.ID, &waypoint.Name, &waypoint.Latitude, &waypoint.Longitude); err != nil {
+		waypoint, err := scanWaypoint(rows)
+		if err != nil {
 
 			return nil, err
 		}
@@ -237,7 +662,342 @@ func (r *routesRepo) RouteWaypoints(ctx context.Context, rID uuid.UUID, rKind in
 	return waypoints, nil
 }
 
-func runWithTx(ctx context.Context, db *pgxpool.Pool, fn func(ctx context.Context, tx pgx.Tx) error) error {
+// AttachWaypoint вставляет остановку на позицию wr.RouteNumber.
+// Позиция 0 или за концом маршрута означает добавление в конец.
+func (r *routesRepo) AttachWaypoint(ctx context.Context, wr domain.WaypointRoute) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		route, pattern, count, err := lockPattern(ctx, tx, wr.RouteID, wr.PatternID)
+		if err != nil {
+
+			return err
+		}
+
+		if route.RouteKind != wr.RouteKind {
+			return fmt.Errorf("%w, route %s has route kind %d", domain.ErrBadRequest, route.ID, route.RouteKind)
+		}
+
+		ok, err := lockVisibleWaypoints(ctx, tx, wr.WaypointID)
+		if err != nil {
+
+			return err
+		}
+
+		if !ok {
+			return fmt.Errorf("%w, waypoint %s", domain.ErrNotFound, wr.WaypointID)
+		}
+
+		position := wr.RouteNumber
+		if position == 0 || position > count+1 {
+			position = count + 1
+		}
+
+		if err := shiftRouteNumbers(ctx, tx, pattern.ID, position, count, 1); err != nil {
+
+			return err
+		}
+
+		insertBuilder := sq.Insert(waypointRoutesTable).
+			Columns("route_id", "pattern_id", "waypoint_id", "route_name", "route_number", "route_kind", "pickup_type", "drop_off_type",
+				"valid_from", "valid_to").
+			Values(route.ID, pattern.ID, wr.WaypointID, route.Name, position, route.RouteKind, wr.PickupType, wr.DropOffType,
+				wr.ValidFrom, wr.ValidTo).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err := insertBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, query, args...)
+		if err != nil {
+			var pqErr *pgconn.PgError
+
+			if errors.As(err, &pqErr) {
+				if pqErr.Code == pgerrcode.UniqueViolation {
+					return fmt.Errorf("%w, %s", domain.ErrConflict, err)
+				}
+
+				if pqErr.Code == pgerrcode.ForeignKeyViolation {
+					return fmt.Errorf("%w, %s", domain.ErrNotFound, err)
+				}
+			}
+
+			return err
+		}
+
+		return updateRouteLength(ctx, tx, pattern, count+1)
+	})
+}
+
+// DetachWaypoint удаляет остановку с варианта маршрута и сдвигает последующие остановки.
+func (r *routesRepo) DetachWaypoint(ctx context.Context, rID, pID, wID uuid.UUID) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		return detachWaypoint(ctx, tx, rID, pID, wID)
+	})
+}
+
+// detachWaypoint удаляет остановку из варианта маршрута в транзакции tx, сдвигая следующие остановки.
+func detachWaypoint(ctx context.Context, tx pgx.Tx, rID, pID, wID uuid.UUID) error {
+	_, pattern, count, err := lockPattern(ctx, tx, rID, pID)
+	if err != nil {
+
+		return err
+	}
+
+	deleteBuilder := sq.Delete(waypointRoutesTable).
+		Where(sq.Eq{"pattern_id": pattern.ID, "waypoint_id": wID, "deleted_at": nil}).
+		Suffix("RETURNING route_number").
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := deleteBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	var position int
+	if err := tx.QueryRow(ctx, query, args...).Scan(&position); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w, waypoint %s is not on route %s", domain.ErrNotFound, wID, rID)
+		}
+
+		return err
+	}
+
+	if err := shiftRouteNumbers(ctx, tx, pattern.ID, position+1, count, -1); err != nil {
+
+		return err
+	}
+
+	return updateRouteLength(ctx, tx, pattern, count-1)
+}
+
+// MoveWaypoint переносит остановку на позицию routeNumber в пределах того же направления.
+func (r *routesRepo) MoveWaypoint(ctx context.Context, rID, pID, wID uuid.UUID, routeNumber int) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		_, pattern, count, err := lockPattern(ctx, tx, rID, pID)
+		if err != nil {
+
+			return err
+		}
+
+		selectBuilder := sq.Select("route_number").
+			From(waypointRoutesTable).
+			Where(sq.Eq{"pattern_id": pattern.ID, "waypoint_id": wID, "deleted_at": nil}).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err := selectBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		var current int
+		if err := tx.QueryRow(ctx, query, args...).Scan(&current); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w, waypoint %s is not on route %s", domain.ErrNotFound, wID, rID)
+			}
+
+			return err
+		}
+
+		target := routeNumber
+		if target == 0 || target > count {
+			target = count
+		}
+
+		switch {
+		case target < current:
+			err = shiftRouteNumbers(ctx, tx, pattern.ID, target, current-1, 1)
+		case target > current:
+			err = shiftRouteNumbers(ctx, tx, pattern.ID, current+1, target, -1)
+		default:
+			return nil
+		}
+		if err != nil {
+
+			return err
+		}
+
+		updateBuilder := sq.Update(waypointRoutesTable).
+			Set("route_number", target).
+			Where(sq.Eq{"pattern_id": pattern.ID, "waypoint_id": wID}).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err = updateBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, query, args...)
+
+		return err
+	})
+}
+
+// SetStopRules изменяет правила посадки/высадки на остановке варианта маршрута.
+func (r *routesRepo) SetStopRules(ctx context.Context, rID, pID, wID uuid.UUID, pickupType, dropOffType string) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		_, pattern, _, err := lockPattern(ctx, tx, rID, pID)
+		if err != nil {
+
+			return err
+		}
+
+		updateBuilder := sq.Update(waypointRoutesTable).
+			Set("pickup_type", pickupType).
+			Set("drop_off_type", dropOffType).
+			Where(sq.Eq{"pattern_id": pattern.ID, "waypoint_id": wID, "deleted_at": nil}).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err := updateBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w, waypoint %s is not on route %s", domain.ErrNotFound, wID, rID)
+		}
+
+		return nil
+	})
+}
+
+// SetStopValidity изменяет период действия остановки в варианте маршрута.
+func (r *routesRepo) SetStopValidity(ctx context.Context, rID, pID, wID uuid.UUID, validity domain.Validity) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		_, pattern, _, err := lockPattern(ctx, tx, rID, pID)
+		if err != nil {
+
+			return err
+		}
+
+		updateBuilder := sq.Update(waypointRoutesTable).
+			Set("valid_from", validity.ValidFrom).
+			Set("valid_to", validity.ValidTo).
+			Where(sq.Eq{"pattern_id": pattern.ID, "waypoint_id": wID, "deleted_at": nil}).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err := updateBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+
+			return err
+		}
+
+		if tag.RowsAffected() == 0 {
+			return fmt.Errorf("%w, waypoint %s is not on route %s", domain.ErrNotFound, wID, rID)
+		}
+
+		return nil
+	})
+}
+
+// lockRoute блокирует строку маршрута до конца транзакции. Изменения всех вариантов маршрута выполняются по очереди.
+// Удаленный маршрут не изменяется. В откатываемой транзакции черновика строка явно не блокируется (см. lockSuffix).
+func lockRoute(ctx context.Context, tx pgx.Tx, rID uuid.UUID) (domain.Route, error) {
+	selectBuilder := sq.Select("id", "name", "route_kind", "length").
+		From(routesTable).
+		Where(sq.Eq{"id": rID, "deleted_at": nil}).
+		Suffix(lockSuffix(ctx, "FOR UPDATE")).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return domain.Route{}, err
+	}
+
+	var route domain.Route
+	if err := tx.QueryRow(ctx, query, args...).Scan(&route.ID, &route.Name, &route.RouteKind, &route.Length); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Route{}, fmt.Errorf("%w, %s", domain.ErrNotFound, err)
+		}
+
+		return domain.Route{}, err
+	}
+
+	return route, nil
+}
+
+// lockPattern блокирует маршрут и возвращает его вариант pID (uuid.Nil - основной) вместе с текущим количеством остановок варианта.
+func lockPattern(ctx context.Context, tx pgx.Tx, rID, pID uuid.UUID) (domain.Route, domain.RoutePattern, int, error) {
+	route, err := lockRoute(ctx, tx, rID)
+	if err != nil {
+
+		return domain.Route{}, domain.RoutePattern{}, 0, err
+	}
+
+	pattern, err := getPattern(ctx, tx, rID, pID)
+	if err != nil {
+
+		return domain.Route{}, domain.RoutePattern{}, 0, err
+	}
+
+	return route, pattern, pattern.Length, nil
+}
+
+// shiftRouteNumbers сдвигает порядковые номера остановок варианта pID в диапазоне [from, to] на delta.
+func shiftRouteNumbers(ctx context.Context, tx pgx.Tx, pID uuid.UUID, from, to, delta int) error {
+	if from > to {
+		return nil
+	}
+
+	updateBuilder := sq.Update(waypointRoutesTable).
+		Set("route_number", sq.Expr("route_number + ?", delta)).
+		Where(sq.Eq{"pattern_id": pID, "deleted_at": nil}).
+		Where(sq.GtOrEq{"route_number": from}).
+		Where(sq.LtOrEq{"route_number": to}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+
+	return err
+}
+
+// updateRouteLength обновляет длину маршрута, если изменился его основной вариант.
+func updateRouteLength(ctx context.Context, tx pgx.Tx, pattern domain.RoutePattern, length int) error {
+	if !pattern.IsDefault {
+		return nil
+	}
+
+	updateBuilder := sq.Update(routesTable).
+		Set("length", length).
+		Where(sq.Eq{"id": pattern.RouteID}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+
+	return err
+}
+
+func runWithTx(ctx context.Context, db conn, fn func(ctx context.Context, tx pgx.Tx) error) error {
 	tx, err := db.Begin(ctx)
 	if err != nil {
 
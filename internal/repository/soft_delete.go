@@ -0,0 +1,407 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// visible возвращает условие видимости записей таблицы alias: запись не удалена и действует на дату из контекста.
+func visible(ctx context.Context, alias string) sq.And {
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+
+	return append(sq.And{sq.Eq{prefix + "deleted_at": nil}}, validAt(ctx, alias)...)
+}
+
+// visibleSql - условие visible для запросов, написанных вручную. Дата передается параметром $n (см. validAtSql).
+func visibleSql(alias string, n int) string {
+	return alias + ".deleted_at IS NULL AND " + validAtSql(alias, n)
+}
+
+// Ссылки остановок маршрутов в waypoint_routes на записи таблиц.
+const (
+	waypointLink = "waypoint_id"
+	routeLink    = "route_id"
+)
+
+// softDelete помечает запись id таблицы table удаленной вместе с ее остановками в маршрутах.
+// link - колонка waypoint_routes, ссылающаяся на запись. Остановки получают то же время удаления,
+// по которому restore находит их при восстановлении, и сохраняют свой номер. Оставшиеся остановки
+// вариантов нумеруются заново, чтобы в нумерации не было пропусков.
+func softDelete(ctx context.Context, db conn, table, link string, id uuid.UUID) error {
+	return runWithTx(ctx, db, func(ctx context.Context, tx pgx.Tx) error {
+		if err := lockStopRoutes(ctx, tx, sq.Eq{link: id, "deleted_at": nil}); err != nil {
+
+			return err
+		}
+
+		query, args, err := sq.Update(table).
+			Set("deleted_at", sq.Expr("NOW()")).
+			Where(sq.Eq{"id": id, "deleted_at": nil}).
+			Suffix("RETURNING deleted_at").
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		var deletedAt time.Time
+		if err := tx.QueryRow(ctx, query, args...).Scan(&deletedAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w, %s %s", domain.ErrNotFound, table, id)
+			}
+
+			return err
+		}
+
+		query, args, err = sq.Update(waypointRoutesTable).
+			Set("deleted_at", deletedAt).
+			Where(sq.Eq{link: id, "deleted_at": nil}).
+			Suffix("RETURNING pattern_id").
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		rows, err := tx.Query(ctx, query, args...)
+		if err != nil {
+
+			return err
+		}
+
+		pIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		if err != nil {
+
+			return err
+		}
+
+		return renumberStops(ctx, tx, pIDs)
+	})
+}
+
+// restore снимает пометку удаления с записи id таблицы table и возвращает остановки, удаленные вместе с ней.
+// Если запись на другой стороне остановки (маршрут или точка, таблица other через колонку otherLink) тоже удалена,
+// остановка переходит к ней и вернется при ее восстановлении. Остальные остановки возвращает restoreStops.
+func restore(ctx context.Context, db conn, table, link, other, otherLink string, id uuid.UUID, restoreStops stopsRestorer) error {
+	return runWithTx(ctx, db, func(ctx context.Context, tx pgx.Tx) error {
+		query, args, err := sq.Select("deleted_at").
+			From(table).
+			Where(sq.Eq{"id": id}).
+			Where(sq.NotEq{"deleted_at": nil}).
+			Suffix("FOR UPDATE").
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		var deletedAt time.Time
+		if err := tx.QueryRow(ctx, query, args...).Scan(&deletedAt); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w, deleted %s %s", domain.ErrNotFound, table, id)
+			}
+
+			return err
+		}
+
+		if err := lockStopRoutes(ctx, tx, sq.Eq{link: id, "deleted_at": deletedAt}); err != nil {
+
+			return err
+		}
+
+		query, args, err = sq.Update(table).
+			Set("deleted_at", nil).
+			Where(sq.Eq{"id": id}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+			var pqErr *pgconn.PgError
+
+			// После удаления могли создать запись с теми же координатами или названием
+			if errors.As(err, &pqErr) && pqErr.Code == pgerrcode.UniqueViolation {
+				return fmt.Errorf("%w, %s", domain.ErrConflict, err)
+			}
+
+			return err
+		}
+
+		query = fmt.Sprintf(`
+    UPDATE %[1]s wr SET deleted_at = o.deleted_at
+    FROM %[2]s o
+    WHERE o.id = wr.%[3]s
+      AND wr.%[4]s = $1
+      AND wr.deleted_at = $2
+      AND o.deleted_at IS NOT NULL;
+		`, waypointRoutesTable, other, otherLink, link)
+
+		if _, err := tx.Exec(ctx, query, id, deletedAt); err != nil {
+
+			return err
+		}
+
+		return restoreStops(ctx, tx, id, deletedAt)
+	})
+}
+
+// stopsRestorer возвращает в маршруты остановки, удаленные в deletedAt вместе с записью id.
+type stopsRestorer func(ctx context.Context, tx pgx.Tx, id uuid.UUID, deletedAt time.Time) error
+
+// reinsertWaypointStops возвращает остановки точки wID на прежние места в вариантах маршрутов.
+// Следующие остановки сдвигаются. Если вариант с тех пор стал короче, остановка становится последней.
+func reinsertWaypointStops(ctx context.Context, tx pgx.Tx, wID uuid.UUID, deletedAt time.Time) error {
+	query, args, err := sq.Select("route_id", "pattern_id", "route_number").
+		From(waypointRoutesTable).
+		Where(sq.Eq{"waypoint_id": wID, "deleted_at": deletedAt}).
+		OrderBy("route_id").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+
+	stops, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.WaypointRoute, error) {
+		var wr domain.WaypointRoute
+		err := row.Scan(&wr.RouteID, &wr.PatternID, &wr.RouteNumber)
+
+		return wr, err
+	})
+	if err != nil {
+
+		return err
+	}
+
+	for _, stop := range stops {
+		_, pattern, count, err := lockPattern(ctx, tx, stop.RouteID, stop.PatternID)
+		if err != nil {
+
+			return err
+		}
+
+		position := min(stop.RouteNumber, count+1)
+
+		if err := shiftRouteNumbers(ctx, tx, pattern.ID, position, count, 1); err != nil {
+
+			return err
+		}
+
+		query, args, err := sq.Update(waypointRoutesTable).
+			Set("deleted_at", nil).
+			Set("route_number", position).
+			Where(sq.Eq{"pattern_id": pattern.ID, "waypoint_id": wID}).
+			PlaceholderFormat(sq.Dollar).
+			ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+
+			return err
+		}
+
+		if err := updateRouteLength(ctx, tx, pattern, count+1); err != nil {
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreRouteStops возвращает остановки маршрута rID. Остановки точек, удаленных после маршрута, остаются скрытыми,
+// поэтому варианты маршрута нумеруются заново.
+func restoreRouteStops(ctx context.Context, tx pgx.Tx, rID uuid.UUID, deletedAt time.Time) error {
+	query, args, err := sq.Update(waypointRoutesTable).
+		Set("deleted_at", nil).
+		Where(sq.Eq{"route_id": rID, "deleted_at": deletedAt}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, query, args...); err != nil {
+
+		return err
+	}
+
+	query, args, err = sq.Select("id").
+		From(routePatternsTable).
+		Where(sq.Eq{"route_id": rID}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+
+	pIDs, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+
+		return err
+	}
+
+	return renumberStops(ctx, tx, pIDs)
+}
+
+// renumberStops нумерует неудаленные остановки вариантов pIDs подряд с 1 в прежнем порядке
+// и пересчитывает длину маршрутов по их основным вариантам.
+func renumberStops(ctx context.Context, tx pgx.Tx, pIDs []uuid.UUID) error {
+	if len(pIDs) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`
+    UPDATE %[1]s wr SET route_number = v.position
+    FROM (
+      SELECT pattern_id, waypoint_id, ROW_NUMBER() OVER (PARTITION BY pattern_id ORDER BY route_number) AS position
+      FROM %[1]s
+      WHERE pattern_id = ANY($1) AND deleted_at IS NULL
+    ) v
+    WHERE wr.pattern_id = v.pattern_id
+      AND wr.waypoint_id = v.waypoint_id
+      AND wr.route_number <> v.position;
+		`, waypointRoutesTable)
+
+	if _, err := tx.Exec(ctx, query, pIDs); err != nil {
+
+		return err
+	}
+
+	query = fmt.Sprintf(`
+    UPDATE %[1]s r SET length = (SELECT COUNT(*) FROM %[2]s wr WHERE wr.pattern_id = p.id AND wr.deleted_at IS NULL)
+    FROM %[3]s p
+    WHERE p.route_id = r.id
+      AND p.is_default
+      AND p.id = ANY($1);
+		`, routesTable, waypointRoutesTable, routePatternsTable)
+
+	_, err := tx.Exec(ctx, query, pIDs)
+
+	return err
+}
+
+// lockStopRoutes блокирует маршруты остановок, выбранных условием stops, в порядке их id,
+// как и изменения списка остановок (lockRoute), чтобы параллельные изменения не нарушили нумерацию.
+func lockStopRoutes(ctx context.Context, tx pgx.Tx, stops sq.Eq) error {
+	stopRoutes, args, err := sq.Select("route_id").
+		From(waypointRoutesTable).
+		Where(stops).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	query, args, err := sq.Select("id").
+		From(routesTable).
+		Where("id IN ("+stopRoutes+")", args...).
+		OrderBy("id").
+		Suffix(lockSuffix(ctx, "FOR UPDATE")).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+
+	_, err = pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+
+	return err
+}
+
+// purge окончательно удаляет записи таблицы table, удаленные раньше before.
+// Их остановки в маршрутах удаляются каскадно.
+func purge(ctx context.Context, db conn, table string, before time.Time) (int64, error) {
+	query, args, err := sq.Delete(table).
+		Where(sq.Lt{"deleted_at": before}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return 0, err
+	}
+
+	tag, err := db.Exec(ctx, query, args...)
+	if err != nil {
+
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// lockVisibleWaypoints блокирует точки ids от удаления до конца транзакции
+// и сообщает, что все они есть и не удалены.
+func lockVisibleWaypoints(ctx context.Context, tx pgx.Tx, ids ...uuid.UUID) (bool, error) {
+	unique := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		unique[id] = struct{}{}
+	}
+
+	query, args, err := sq.Select("id").
+		From(waypointTable).
+		Where(sq.Eq{"id": ids, "deleted_at": nil}).
+		Suffix(lockSuffix(ctx, "FOR SHARE")).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return false, err
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+
+		return false, err
+	}
+
+	found, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+
+		return false, err
+	}
+
+	return len(found) == len(unique), nil
+}
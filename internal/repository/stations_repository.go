@@ -0,0 +1,308 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	stationsTable = "stations"
+)
+
+type stationsRepo struct {
+	db conn
+}
+
+func NewStationsRepo(db *pgxpool.Pool) domain.StationsRepository {
+	return &stationsRepo{db: conn{pool: db}}
+}
+
+// stationSelect выбирает станции с центром, вычисленным по остановкам.
+func stationSelect() sq.SelectBuilder {
+	return sq.Select("s.id", "s.name", "COALESCE(AVG(w.latitude), 0)", "COALESCE(AVG(w.longitude), 0)").
+		From(stationsTable + " s").
+		LeftJoin(waypointTable + " w ON w.station_id = s.id AND w.deleted_at IS NULL").
+		GroupBy("s.id").
+		PlaceholderFormat(sq.Dollar)
+}
+
+func (r *stationsRepo) List(ctx context.Context) ([]domain.Station, error) {
+	query, args, err := stationSelect().OrderBy("s.name").ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	var stations []domain.Station
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var station domain.Station
+		if err := rows.Scan(&station.ID, &station.Name, &station.Latitude, &station.Longitude); err != nil {
+
+			return nil, err
+		}
+		stations = append(stations, station)
+	}
+
+	return stations, rows.Err()
+}
+
+func (r *stationsRepo) GetById(ctx context.Context, id uuid.UUID) (domain.Station, error) {
+	query, args, err := stationSelect().Where(sq.Eq{"s.id": id}).ToSql()
+	if err != nil {
+
+		return domain.Station{}, err
+	}
+
+	var station domain.Station
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&station.ID, &station.Name, &station.Latitude, &station.Longitude); err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Station{}, fmt.Errorf("%w, %s", domain.ErrNotFound, err)
+		}
+
+		return domain.Station{}, err
+	}
+
+	return station, nil
+}
+
+// Create создает станцию и переносит в нее остановки waypointIds (из других станций тоже).
+func (r *stationsRepo) Create(ctx context.Context, station domain.Station, waypointIds []uuid.UUID) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		insertBuilder := sq.Insert(stationsTable).
+			Columns("id", "name").
+			Values(station.ID, station.Name).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err := insertBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, query, args...); err != nil {
+
+			return err
+		}
+
+		if len(waypointIds) == 0 {
+			return nil
+		}
+
+		updateBuilder := sq.Update(waypointTable).
+			Set("station_id", station.ID).
+			Where(sq.Eq{"id": waypointIds, "deleted_at": nil}).
+			PlaceholderFormat(sq.Dollar)
+
+		query, args, err = updateBuilder.ToSql()
+		if err != nil {
+
+			return err
+		}
+
+		tag, err := tx.Exec(ctx, query, args...)
+		if err != nil {
+
+			return err
+		}
+
+		if tag.RowsAffected() != int64(len(waypointIds)) {
+			return fmt.Errorf("%w, some waypoints do not exist", domain.ErrNotFound)
+		}
+
+		return nil
+	})
+}
+
+func (r *stationsRepo) Update(ctx context.Context, station domain.Station) error {
+	updateBuilder := sq.Update(stationsTable).
+		Set("name", station.Name).
+		Where(sq.Eq{"id": station.ID}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w, station not found", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Delete удаляет станцию. Остановки остаются и перестают входить в станцию (ON DELETE SET NULL).
+func (r *stationsRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	deleteBuilder := sq.Delete(stationsTable).
+		Where(sq.Eq{"id": id}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := deleteBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w, station not found", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *stationsRepo) Members(ctx context.Context, id uuid.UUID) ([]domain.Waypoint, error) {
+	selectBuilder := sq.Select(waypointColumns...).
+		From(waypointTable).
+		Where(sq.Eq{"station_id": id}).
+		Where(visible(ctx, "")).
+		OrderBy("name", "platform_code", "id").
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	var waypoints []domain.Waypoint
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		waypoint, err := scanWaypoint(rows)
+		if err != nil {
+
+			return nil, err
+		}
+		waypoints = append(waypoints, waypoint)
+	}
+
+	return waypoints, rows.Err()
+}
+
+func (r *stationsRepo) AddMember(ctx context.Context, id, wID uuid.UUID) error {
+	updateBuilder := sq.Update(waypointTable).
+		Set("station_id", id).
+		Where(sq.Eq{"id": wID, "deleted_at": nil}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		var pqErr *pgconn.PgError
+
+		if errors.As(err, &pqErr) && pqErr.Code == pgerrcode.ForeignKeyViolation {
+			return fmt.Errorf("%w, station not found", domain.ErrNotFound)
+		}
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w, waypoint not found", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *stationsRepo) RemoveMember(ctx context.Context, id, wID uuid.UUID) error {
+	updateBuilder := sq.Update(waypointTable).
+		Set("station_id", nil).
+		Where(sq.Eq{"id": wID, "station_id": id}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w, waypoint is not a member of the station", domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *stationsRepo) SiblingIds(ctx context.Context, wID uuid.UUID) ([]uuid.UUID, error) {
+	query := `
+    SELECT s.id
+    FROM waypoints w
+    JOIN waypoints s ON (s.id = w.id OR s.station_id = w.station_id) AND s.deleted_at IS NULL
+    WHERE w.id = $1 AND w.deleted_at IS NULL
+    ORDER BY s.id = $1 DESC;
+	`
+
+	rows, err := r.db.Query(ctx, query, wID)
+	if err != nil {
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+
+		return nil, err
+	}
+
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w, waypoint not found", domain.ErrNotFound)
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const (
+	waypointTranslationsTable = "waypoint_translations"
+	routeTranslationsTable    = "route_translations"
+)
+
+func (r *waypointRepo) Translations(ctx context.Context, lang string, ids ...uuid.UUID) (map[uuid.UUID]string, error) {
+	return translations(ctx, r.db, waypointTranslationsTable, "waypoint_id", lang, ids)
+}
+
+func (r *waypointRepo) ListTranslations(ctx context.Context, id uuid.UUID) ([]domain.Translation, error) {
+	return listTranslations(ctx, r.db, waypointTranslationsTable, "waypoint_id", id)
+}
+
+func (r *waypointRepo) SetTranslation(ctx context.Context, id uuid.UUID, translation domain.Translation) error {
+	return setTranslation(ctx, r.db, waypointTranslationsTable, "waypoint_id", id, translation)
+}
+
+func (r *waypointRepo) DeleteTranslation(ctx context.Context, id uuid.UUID, lang string) error {
+	return deleteTranslation(ctx, r.db, waypointTranslationsTable, "waypoint_id", id, lang)
+}
+
+func (r *routesRepo) Translations(ctx context.Context, lang string, ids ...uuid.UUID) (map[uuid.UUID]string, error) {
+	return translations(ctx, r.db, routeTranslationsTable, "route_id", lang, ids)
+}
+
+func (r *routesRepo) ListTranslations(ctx context.Context, id uuid.UUID) ([]domain.Translation, error) {
+	return listTranslations(ctx, r.db, routeTranslationsTable, "route_id", id)
+}
+
+func (r *routesRepo) SetTranslation(ctx context.Context, id uuid.UUID, translation domain.Translation) error {
+	return setTranslation(ctx, r.db, routeTranslationsTable, "route_id", id, translation)
+}
+
+func (r *routesRepo) DeleteTranslation(ctx context.Context, id uuid.UUID, lang string) error {
+	return deleteTranslation(ctx, r.db, routeTranslationsTable, "route_id", id, lang)
+}
+
+func translations(ctx context.Context, db conn, table, idColumn, lang string, ids []uuid.UUID) (map[uuid.UUID]string, error) {
+	names := make(map[uuid.UUID]string, len(ids))
+
+	if len(ids) == 0 {
+		return names, nil
+	}
+
+	selectBuilder := sq.Select(idColumn, "name").
+		From(table).
+		Where(sq.Eq{idColumn: ids, "lang": lang}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id   uuid.UUID
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+
+			return nil, err
+		}
+		names[id] = name
+	}
+
+	return names, rows.Err()
+}
+
+func listTranslations(ctx context.Context, db conn, table, idColumn string, id uuid.UUID) ([]domain.Translation, error) {
+	selectBuilder := sq.Select("lang", "name").
+		From(table).
+		Where(sq.Eq{idColumn: id}).
+		OrderBy("lang").
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	rows, err := db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []domain.Translation
+	for rows.Next() {
+		var translation domain.Translation
+		if err := rows.Scan(&translation.Lang, &translation.Name); err != nil {
+
+			return nil, err
+		}
+		result = append(result, translation)
+	}
+
+	return result, rows.Err()
+}
+
+func setTranslation(ctx context.Context, db conn, table, idColumn string, id uuid.UUID, translation domain.Translation) error {
+	insertBuilder := sq.Insert(table).
+		Columns(idColumn, "lang", "name").
+		Values(id, translation.Lang, translation.Name).
+		Suffix(fmt.Sprintf("ON CONFLICT (%s, lang) DO UPDATE SET name = EXCLUDED.name", idColumn)).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	_, err = db.Exec(ctx, query, args...)
+	if err != nil {
+		var pqErr *pgconn.PgError
+
+		if errors.As(err, &pqErr) {
+			if pqErr.Code == pgerrcode.ForeignKeyViolation {
+				return fmt.Errorf("%w, %s", domain.ErrNotFound, err)
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func deleteTranslation(ctx context.Context, db conn, table, idColumn string, id uuid.UUID, lang string) error {
+	deleteBuilder := sq.Delete(table).
+		Where(sq.Eq{idColumn: id, "lang": lang}).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := deleteBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	tag, err := db.Exec(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w, translation %s for %s", domain.ErrNotFound, lang, id)
+	}
+
+	return nil
+}
@@ -0,0 +1,167 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+var typeColumns = []string{"code", "display_name", "icon_key", "color", "default_speed", "gtfs_route_type"}
+
+// typesRepo работает с одним справочником типов: table - domain.VehicleTypes или domain.RouteTypes.
+type typesRepo struct {
+	db    conn
+	table string
+}
+
+func NewTypesRepo(db *pgxpool.Pool, table string) domain.TypesRepository {
+	return &typesRepo{db: conn{pool: db}, table: table}
+}
+
+func scanTypeEntry(row pgx.CollectableRow) (domain.TypeEntry, error) {
+	var entry domain.TypeEntry
+	err := row.Scan(&entry.Code, &entry.DisplayName, &entry.IconKey, &entry.Color, &entry.DefaultSpeed, &entry.GTFSRouteType)
+
+	return entry, err
+}
+
+func (r *typesRepo) List(ctx context.Context) ([]domain.TypeEntry, error) {
+	query, args, err := sq.Select(typeColumns...).
+		From(r.table).
+		OrderBy("code").
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanTypeEntry)
+}
+
+func (r *typesRepo) Get(ctx context.Context, code string) (domain.TypeEntry, error) {
+	query, args, err := sq.Select(typeColumns...).
+		From(r.table).
+		Where(sq.Eq{"code": code}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return domain.TypeEntry{}, err
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return domain.TypeEntry{}, err
+	}
+
+	entry, err := pgx.CollectExactlyOneRow(rows, scanTypeEntry)
+	if err != nil {
+
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.TypeEntry{}, fmt.Errorf("%w, %s %s", domain.ErrNotFound, r.table, code)
+		}
+
+		return domain.TypeEntry{}, err
+	}
+
+	return entry, nil
+}
+
+func (r *typesRepo) Create(ctx context.Context, entry domain.TypeEntry) error {
+	query, args, err := sq.Insert(r.table).
+		Columns(typeColumns...).
+		Values(entry.Code, entry.DisplayName, entry.IconKey, entry.Color, entry.DefaultSpeed, entry.GTFSRouteType).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	if _, err := r.db.Exec(ctx, query, args...); err != nil {
+		var pqErr *pgconn.PgError
+
+		if errors.As(err, &pqErr) {
+			if pqErr.Code == pgerrcode.UniqueViolation {
+				return fmt.Errorf("%w, %s", domain.ErrConflict, err)
+			}
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (r *typesRepo) Update(ctx context.Context, entry domain.TypeEntry) error {
+	query, args, err := sq.Update(r.table).
+		Set("display_name", entry.DisplayName).
+		Set("icon_key", entry.IconKey).
+		Set("color", entry.Color).
+		Set("default_speed", entry.DefaultSpeed).
+		Set("gtfs_route_type", entry.GTFSRouteType).
+		Where(sq.Eq{"code": entry.Code}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w, %s %s", domain.ErrNotFound, r.table, entry.Code)
+	}
+
+	return nil
+}
+
+func (r *typesRepo) Delete(ctx context.Context, code string) error {
+	query, args, err := sq.Delete(r.table).
+		Where(sq.Eq{"code": code}).
+		PlaceholderFormat(sq.Dollar).
+		ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		var pqErr *pgconn.PgError
+
+		if errors.As(err, &pqErr) {
+			if pqErr.Code == pgerrcode.ForeignKeyViolation {
+				return fmt.Errorf("%w, %s", domain.ErrConflict, err)
+			}
+		}
+
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w, %s %s", domain.ErrNotFound, r.table, code)
+	}
+
+	return nil
+}
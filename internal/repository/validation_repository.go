@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type validationRepo struct {
+	db conn
+}
+
+func NewValidationRepo(db *pgxpool.Pool) domain.ValidationRepository {
+	return &validationRepo{db: conn{pool: db}}
+}
+
+// LengthMismatches сравнивает длину маршрута с количеством остановок его основного варианта.
+func (r *validationRepo) LengthMismatches(ctx context.Context) ([]domain.NetworkIssue, error) {
+	query := `
+    SELECT r.id, r.name, r.route_kind, r.length, COUNT(wr.waypoint_id)
+    FROM routes r
+    LEFT JOIN route_patterns p ON p.route_id = r.id AND p.is_default
+    LEFT JOIN waypoint_routes wr ON wr.pattern_id = p.id AND wr.deleted_at IS NULL
+    WHERE r.deleted_at IS NULL
+    GROUP BY r.id
+    HAVING r.length <> COUNT(wr.waypoint_id)
+    ORDER BY r.name, r.route_kind;
+	`
+
+	return r.collectIssues(ctx, query, nil, func(row pgx.CollectableRow) (domain.NetworkIssue, error) {
+		var (
+			id           uuid.UUID
+			name         string
+			kind, length int
+			count        int
+		)
+		if err := row.Scan(&id, &name, &kind, &length, &count); err != nil {
+			return domain.NetworkIssue{}, err
+		}
+
+		return domain.NetworkIssue{
+			Check:    domain.CheckRouteLength,
+			Severity: domain.SeverityError,
+			Message:  fmt.Sprintf("route %s (kind %d) has length %d but %d stops", name, kind, length, count),
+			RouteID:  &id,
+		}, nil
+	})
+}
+
+// NumberingIssues находит варианты маршрутов, у которых route_number не образует последовательность 1..N.
+func (r *validationRepo) NumberingIssues(ctx context.Context) ([]domain.NetworkIssue, error) {
+	query := `
+    SELECT r.id, r.name, r.route_kind, p.name,
+      COUNT(*), COUNT(DISTINCT wr.route_number), MIN(wr.route_number), MAX(wr.route_number)
+    FROM routes r
+    JOIN route_patterns p ON p.route_id = r.id
+    JOIN waypoint_routes wr ON wr.pattern_id = p.id AND wr.deleted_at IS NULL
+    WHERE r.deleted_at IS NULL
+    GROUP BY r.id, p.id
+    HAVING COUNT(DISTINCT wr.route_number) <> COUNT(*)
+      OR MIN(wr.route_number) <> 1
+      OR MAX(wr.route_number) <> COUNT(*)
+    ORDER BY r.name, r.route_kind, p.name;
+	`
+
+	return r.collectIssues(ctx, query, nil, func(row pgx.CollectableRow) (domain.NetworkIssue, error) {
+		var (
+			id                    uuid.UUID
+			name, pattern         string
+			kind, count, distinct int
+			minNumber, maxNumber  int
+		)
+		if err := row.Scan(&id, &name, &kind, &pattern, &count, &distinct, &minNumber, &maxNumber); err != nil {
+			return domain.NetworkIssue{}, err
+		}
+
+		message := fmt.Sprintf("route %s (kind %d, pattern %s) has %d stops numbered %d..%d", name, kind, pattern, count, minNumber, maxNumber)
+		if distinct != count {
+			message += fmt.Sprintf(", %d duplicate numbers", count-distinct)
+		}
+
+		return domain.NetworkIssue{
+			Check:    domain.CheckRouteNumbering,
+			Severity: domain.SeverityError,
+			Message:  message,
+			RouteID:  &id,
+		}, nil
+	})
+}
+
+// StopGaps находит соседние остановки маршрута дальше maxGap метров друг от друга или совпадающие.
+func (r *validationRepo) StopGaps(ctx context.Context, maxGap float64) ([]domain.NetworkIssue, error) {
+	query := `
+    SELECT route_id, name, route_kind, pattern_name, route_number, waypoint_id, next_id, distance
+    FROM (
+      SELECT wr.route_id, r.name, r.route_kind, p.name AS pattern_name, wr.route_number, wr.waypoint_id,
+        LEAD(wr.waypoint_id) OVER w AS next_id,
+        ST_Distance(wp.geom::geography, (LEAD(wp.geom) OVER w)::geography) AS distance
+      FROM waypoint_routes wr
+      JOIN routes r ON r.id = wr.route_id
+      JOIN route_patterns p ON p.id = wr.pattern_id
+      JOIN waypoints wp ON wp.id = wr.waypoint_id
+      WHERE wr.deleted_at IS NULL
+      WINDOW w AS (PARTITION BY wr.pattern_id ORDER BY wr.route_number)
+    ) pairs
+    WHERE next_id IS NOT NULL
+      AND (distance > $1 OR distance < 1 OR waypoint_id = next_id)
+    ORDER BY name, route_kind, pattern_name, route_number;
+	`
+
+	return r.collectIssues(ctx, query, []any{maxGap}, func(row pgx.CollectableRow) (domain.NetworkIssue, error) {
+		var (
+			rID, wID, nextID uuid.UUID
+			name, pattern    string
+			kind, number     int
+			distance         float64
+		)
+		if err := row.Scan(&rID, &name, &kind, &pattern, &number, &wID, &nextID, &distance); err != nil {
+			return domain.NetworkIssue{}, err
+		}
+
+		if distance > maxGap {
+			return domain.NetworkIssue{
+				Check:      domain.CheckStopGap,
+				Severity:   domain.SeverityWarning,
+				Message:    fmt.Sprintf("route %s (kind %d, pattern %s): stops %d and %d are %.0f m apart", name, kind, pattern, number, number+1, distance),
+				RouteID:    &rID,
+				WaypointID: &wID,
+			}, nil
+		}
+
+		return domain.NetworkIssue{
+			Check:      domain.CheckIdenticalStops,
+			Severity:   domain.SeverityError,
+			Message:    fmt.Sprintf("route %s (kind %d, pattern %s): stops %d and %d are identical", name, kind, pattern, number, number+1),
+			RouteID:    &rID,
+			WaypointID: &wID,
+		}, nil
+	})
+}
+
+func (r *validationRepo) OrphanStops(ctx context.Context) ([]domain.NetworkIssue, error) {
+	query := `
+    SELECT w.id, w.name
+    FROM waypoints w
+    WHERE w.deleted_at IS NULL
+      AND NOT EXISTS (SELECT 1 FROM waypoint_routes wr WHERE wr.waypoint_id = w.id AND wr.deleted_at IS NULL)
+    ORDER BY w.name;
+	`
+
+	return r.collectIssues(ctx, query, nil, func(row pgx.CollectableRow) (domain.NetworkIssue, error) {
+		var (
+			id   uuid.UUID
+			name string
+		)
+		if err := row.Scan(&id, &name); err != nil {
+			return domain.NetworkIssue{}, err
+		}
+
+		return domain.NetworkIssue{
+			Check:      domain.CheckOrphanStop,
+			Severity:   domain.SeverityWarning,
+			Message:    fmt.Sprintf("stop %s is not served by any route", name),
+			WaypointID: &id,
+		}, nil
+	})
+}
+
+// MissingDirections находит маршруты, у которых есть только одно из направлений 1 и 2.
+// Маршруты с route_kind 0 (без направления) и кольцевые маршруты не проверяются.
+func (r *validationRepo) MissingDirections(ctx context.Context) ([]domain.NetworkIssue, error) {
+	query := `
+    SELECT MIN(id::TEXT)::UUID, name, MIN(route_kind)
+    FROM routes
+    WHERE route_kind IN (1, 2) AND NOT is_loop AND deleted_at IS NULL
+    GROUP BY name
+    HAVING COUNT(DISTINCT route_kind) = 1
+    ORDER BY name;
+	`
+
+	return r.collectIssues(ctx, query, nil, func(row pgx.CollectableRow) (domain.NetworkIssue, error) {
+		var (
+			id   uuid.UUID
+			name string
+			kind int
+		)
+		if err := row.Scan(&id, &name, &kind); err != nil {
+			return domain.NetworkIssue{}, err
+		}
+
+		opposite, _ := domain.OppositeRouteKind(kind)
+
+		return domain.NetworkIssue{
+			Check:    domain.CheckMissingDirection,
+			Severity: domain.SeverityWarning,
+			Message:  fmt.Sprintf("route %s has direction %d but no direction %d", name, kind, opposite),
+			RouteID:  &id,
+		}, nil
+	})
+}
+
+func (r *validationRepo) collectIssues(ctx context.Context, query string, args []any, fn pgx.RowToFunc[domain.NetworkIssue]) ([]domain.NetworkIssue, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, fn)
+}
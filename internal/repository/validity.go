@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+// validAt возвращает условие действия записей таблицы alias на дату из контекста.
+// Если дата не задана, условие всегда истинно.
+func validAt(ctx context.Context, alias string) sq.And {
+	at, ok := domain.ValidAtFromContext(ctx)
+	if !ok {
+		return sq.And{}
+	}
+
+	prefix := ""
+	if alias != "" {
+		prefix = alias + "."
+	}
+
+	return sq.And{
+		sq.Or{sq.Eq{prefix + "valid_from": nil}, sq.LtOrEq{prefix + "valid_from": at}},
+		sq.Or{sq.Eq{prefix + "valid_to": nil}, sq.GtOrEq{prefix + "valid_to": at}},
+	}
+}
+
+// validAtSql - условие validAt для запросов, написанных вручную. Дата передается параметром $n,
+// его значение возвращает validAtArg.
+func validAtSql(alias string, n int) string {
+	return fmt.Sprintf(
+		"($%[2]d::DATE IS NULL OR ((%[1]s.valid_from IS NULL OR %[1]s.valid_from <= $%[2]d) AND (%[1]s.valid_to IS NULL OR %[1]s.valid_to >= $%[2]d)))",
+		alias, n,
+	)
+}
+
+// validAtArg возвращает дату из контекста или nil, если дата не задана.
+func validAtArg(ctx context.Context) any {
+	if at, ok := domain.ValidAtFromContext(ctx); ok {
+		return at
+	}
+
+	return nil
+}
+
+// dateOrNull возвращает nil для нулевой даты (без ограничения).
+func dateOrNull(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
@@ -2,11 +2,15 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/i18n"
 	"github.com/google/uuid"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5"
@@ -19,18 +23,27 @@ const (
 	waypointRoutesTable = "waypoint_routes"
 )
 
+// Колонки точки в порядке, ожидаемом scanWaypoint.
+var waypointColumns = []string{
+	"id", "name", "latitude", "longitude",
+	"wheelchair_boarding", "shelter", "bench", "lighting", "tactile_paving", "platform_code", "description",
+	"valid_from", "valid_to",
+}
+
 type waypointRepo struct {
-	db *pgxpool.Pool
+	db conn
 }
 
 func NewWaypointRepo(db *pgxpool.Pool) domain.WaypointsRepository {
-	return &waypointRepo{db: db}
+	return &waypointRepo{db: conn{pool: db}}
 }
 
 func (r *waypointRepo) Create(ctx context.Context, waypoint domain.Waypoint) error {
 	insertBuilder := sq.Insert(waypointTable).
-		Columns("id", "name", "latitude", "longitude", "geom").
+		Columns(append(waypointColumns, "geom")...).
 		Values(waypoint.ID, waypoint.Name, waypoint.Latitude, waypoint.Longitude,
+			waypoint.WheelchairBoarding, waypoint.Shelter, waypoint.Bench, waypoint.Lighting, waypoint.TactilePaving,
+			waypoint.PlatformCode, waypoint.Description, waypoint.ValidFrom, waypoint.ValidTo,
 			fmt.Sprintf("SRID=4326;POINT(%f %f)", waypoint.Longitude, waypoint.Latitude)).
 		PlaceholderFormat(sq.Dollar)
 
@@ -57,10 +70,117 @@ func (r *waypointRepo) Create(ctx context.Context, waypoint domain.Waypoint) err
 	return nil
 }
 
+// CreateMany копирует точки во временную таблицу через COPY и переносит их в waypoints одним запросом.
+// Возвращает идентификаторы реально созданных точек в порядке входного списка.
+func (r *waypointRepo) CreateMany(ctx context.Context, waypoints []domain.Waypoint, mode domain.BatchMode) ([]uuid.UUID, error) {
+	var created []uuid.UUID
+
+	err := runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, `
+		CREATE TEMP TABLE waypoints_batch (
+			ord INTEGER NOT NULL,
+			id UUID NOT NULL,
+			name VARCHAR(255) NOT NULL,
+			latitude DOUBLE PRECISION NOT NULL,
+			longitude DOUBLE PRECISION NOT NULL,
+			wheelchair_boarding TEXT NOT NULL,
+			shelter BOOLEAN,
+			bench BOOLEAN,
+			lighting BOOLEAN,
+			tactile_paving BOOLEAN,
+			platform_code TEXT NOT NULL,
+			description TEXT NOT NULL,
+			valid_from DATE,
+			valid_to DATE
+		) ON COMMIT DROP;
+		`)
+		if err != nil {
+
+			return err
+		}
+
+		_, err = tx.CopyFrom(ctx,
+			pgx.Identifier{"waypoints_batch"},
+			append([]string{"ord"}, waypointColumns...),
+			pgx.CopyFromSlice(len(waypoints), func(i int) ([]any, error) {
+				wp := waypoints[i]
+
+				return []any{
+					i, wp.ID, wp.Name, wp.Latitude, wp.Longitude,
+					wp.WheelchairBoarding, wp.Shelter, wp.Bench, wp.Lighting, wp.TactilePaving, wp.PlatformCode, wp.Description,
+					wp.ValidFrom, wp.ValidTo,
+				}, nil
+			}),
+		)
+		if err != nil {
+
+			return err
+		}
+
+		onConflict := ""
+		if mode == domain.BatchModeBestEffort {
+			onConflict = "ON CONFLICT DO NOTHING"
+		}
+
+		query := fmt.Sprintf(`
+		WITH inserted AS (
+			INSERT INTO waypoints (
+				id, name, latitude, longitude,
+				wheelchair_boarding, shelter, bench, lighting, tactile_paving, platform_code, description,
+				valid_from, valid_to, geom
+			)
+			SELECT id, name, latitude, longitude,
+				wheelchair_boarding::WHEELCHAIR_BOARDING_ENUM, shelter, bench, lighting, tactile_paving, platform_code, description,
+				valid_from, valid_to, ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)
+			FROM waypoints_batch
+			ORDER BY ord
+			%s
+			RETURNING id
+		)
+		SELECT b.id
+		FROM waypoints_batch b
+		JOIN inserted i ON i.id = b.id
+		ORDER BY b.ord;
+		`, onConflict)
+
+		rows, err := tx.Query(ctx, query)
+		if err != nil {
+
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id uuid.UUID
+			if err := rows.Scan(&id); err != nil {
+
+				return err
+			}
+			created = append(created, id)
+		}
+
+		return rows.Err()
+	})
+	if err != nil {
+		var pqErr *pgconn.PgError
+
+		if errors.As(err, &pqErr) {
+			if pqErr.Code == pgerrcode.UniqueViolation {
+				return nil, fmt.Errorf("%w, %s", domain.ErrConflict, err)
+			}
+		}
+
+		return nil, err
+	}
+
+	return created, nil
+}
+
 func (r *waypointRepo) GetById(ctx context.Context, id uuid.UUID) (domain.Waypoint, error) {
-	selectBuilder := sq.Select("id", "name", "latitude", "longitude").
+	selectBuilder := sq.Select(waypointColumns...).
 		From(waypointTable).
 		Where(sq.Eq{"id": id}).
+		Where(visible(ctx, "")).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := selectBuilder.ToSql()
@@ -69,8 +189,8 @@ func (r *waypointRepo) GetById(ctx context.Context, id uuid.UUID) (domain.Waypoi
 		return domain.Waypoint{}, err
 	}
 
-	var waypoint domain.Waypoint
-	if err := r.db.QueryRow(ctx, query, args...).Scan(&waypoint.ID, &waypoint.Name, &waypoint.Latitude, &waypoint.Longitude); err != nil {
+	waypoint, err := scanWaypoint(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
 
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Waypoint{}, fmt.Errorf("%w, %s", domain.ErrNotFound, err)
@@ -82,11 +202,280 @@ func (r *waypointRepo) GetById(ctx context.Context, id uuid.UUID) (domain.Waypoi
 	return waypoint, nil
 }
 
-func (r *waypointRepo) List(ctx context.Context, limit, offset uint64) ([]domain.Waypoint, error) {
-	selectBuilder := sq.Select("id", "name", "latitude", "longitude").
+// List возвращает страницу точек, упорядоченных по id.
+// С курсором используется keyset-пагинация (id > курсор), иначе limit/offset.
+func (r *waypointRepo) List(ctx context.Context, page domain.Page, filter domain.WaypointFilter) (domain.WaypointsPage, error) {
+	selectBuilder := sq.Select(waypointColumns...).
+		From(waypointTable).
+		Where(waypointFilterToSql(filter)).
+		Where(visible(ctx, "")).
+		OrderBy("id").
+		Limit(page.Limit + 1).
+		PlaceholderFormat(sq.Dollar)
+
+	if page.Cursor != "" {
+		var cursor waypointCursor
+		if err := decodeCursor(page.Cursor, &cursor); err != nil {
+
+			return domain.WaypointsPage{}, err
+		}
+
+		selectBuilder = selectBuilder.Where(sq.Gt{"id": cursor.ID})
+	} else {
+		selectBuilder = selectBuilder.Offset(page.Offset)
+	}
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return domain.WaypointsPage{}, err
+	}
+
+	var waypoints []domain.Waypoint
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return domain.WaypointsPage{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		waypoint, err := scanWaypoint(rows)
+		if err != nil {
+
+			return domain.WaypointsPage{}, err
+		}
+		waypoints = append(waypoints, waypoint)
+	}
+
+	if err := rows.Err(); err != nil {
+
+		return domain.WaypointsPage{}, err
+	}
+
+	var result domain.WaypointsPage
+
+	if uint64(len(waypoints)) > page.Limit {
+		waypoints = waypoints[:page.Limit]
+
+		if len(waypoints) > 0 {
+			result.NextCursor, err = encodeCursor(waypointCursor{ID: waypoints[len(waypoints)-1].ID})
+			if err != nil {
+
+				return domain.WaypointsPage{}, err
+			}
+		}
+	}
+
+	result.Waypoints = waypoints
+
+	countBuilder := sq.Select("COUNT(*)").
+		From(waypointTable).
+		Where(waypointFilterToSql(filter)).
+		Where(visible(ctx, "")).
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err = countBuilder.ToSql()
+	if err != nil {
+
+		return domain.WaypointsPage{}, err
+	}
+
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&result.Total); err != nil {
+
+		return domain.WaypointsPage{}, err
+	}
+
+	return result, nil
+}
+
+// GetOfNearest возвращает до search.Amount ближайших точек с расстоянием (по сфероиду) и азимутом от заданных координат.
+func (r *waypointRepo) GetOfNearest(ctx context.Context, search domain.NearestSearch) ([]domain.NearbyWaypoint, error) {
+	point := "ST_SetSRID(ST_MakePoint(?, ?), 4326)"
+
+	selectBuilder := sq.Select(waypointColumns...).
+		Column(sq.Alias(sq.Expr("ST_Distance(geom::geography, "+point+"::geography)", search.Longitude, search.Latitude), "distance")).
+		Column(sq.Expr("COALESCE(degrees(ST_Azimuth("+point+"::geography, geom::geography)), 0)", search.Longitude, search.Latitude)).
+		From(waypointTable).
+		Where(visible(ctx, "")).
+		// KNN-сортировка по индексу idx_waypoints_geog: расстояние и азимут считаются только для попавших в limit точек
+		OrderByClause("geom::geography <-> "+point+"::geography", search.Longitude, search.Latitude).
+		Limit(uint64(search.Amount)).
+		PlaceholderFormat(sq.Dollar)
+
+	if search.Radius != nil {
+		selectBuilder = selectBuilder.Where(sq.Expr(
+			"ST_DWithin(geom::geography, "+point+"::geography, ?)",
+			search.Longitude, search.Latitude, *search.Radius,
+		))
+	}
+
+	routeConditions := nearestRouteFilterToSql(search)
+	if len(routeConditions) > 0 {
+		servedBy := sq.Select("1").
+			From(waypointRoutesTable + " wr").
+			Join(routesTable + " r ON r.id = wr.route_id").
+			Where("wr.waypoint_id = " + waypointTable + ".id").
+			Where(routeConditions).
+			Where(visible(ctx, "wr")).
+			Where(visible(ctx, "r"))
+
+		selectBuilder = selectBuilder.Where(sq.Expr("EXISTS (?)", servedBy))
+	}
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	var waypoints []domain.NearbyWaypoint
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var waypoint domain.NearbyWaypoint
+		if err := rows.Scan(append(waypointDest(&waypoint.Waypoint), &waypoint.Distance, &waypoint.Bearing)...); err != nil {
+
+			return nil, err
+		}
+		waypoints = append(waypoints, waypoint)
+	}
+
+	if err := rows.Err(); err != nil {
+
+		return nil, err
+	}
+
+	if err := r.fillNearbyRoutes(ctx, waypoints, routeConditions); err != nil {
+
+		return nil, err
+	}
+
+	return waypoints, nil
+}
+
+// fillNearbyRoutes заполняет маршруты, проходящие через найденные точки и подходящие под conditions.
+func (r *waypointRepo) fillNearbyRoutes(ctx context.Context, waypoints []domain.NearbyWaypoint, conditions sq.And) error {
+	if len(waypoints) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(waypoints))
+	index := make(map[uuid.UUID]int, len(waypoints))
+	for i, waypoint := range waypoints {
+		ids[i] = waypoint.ID
+		index[waypoint.ID] = i
+	}
+
+	// Остановка может входить в несколько вариантов одного маршрута, поэтому строки группируются по маршруту.
+	selectBuilder := sq.Select("wr.waypoint_id").
+		Columns(routeColumns("r")...).
+		From(waypointRoutesTable+" wr").
+		Join(routesTable+" r ON r.id = wr.route_id").
+		Where(sq.Expr("wr.waypoint_id = ANY(?)", ids)).
+		Where(conditions).
+		Where(visible(ctx, "wr")).
+		Where(visible(ctx, "r")).
+		GroupBy("wr.waypoint_id", "r.id").
+		OrderBy(routeNaturalKey, "r.name", "r.route_kind").
+		PlaceholderFormat(sq.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return err
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			wID   uuid.UUID
+			route domain.Route
+		)
+		if err := rows.Scan(append([]any{&wID}, routeDest(&route)...)...); err != nil {
+
+			return err
+		}
+
+		waypoint := &waypoints[index[wID]]
+		waypoint.Routes = append(waypoint.Routes, route)
+	}
+
+	if err := rows.Err(); err != nil {
+
+		return err
+	}
+
+	var refs []*domain.Route
+	for i := range waypoints {
+		refs = append(refs, routeRefs(waypoints[i].Routes)...)
+	}
+
+	return fillDefaultLongNames(ctx, r.db, refs...)
+}
+
+func nearestRouteFilterToSql(search domain.NearestSearch) sq.And {
+	conditions := sq.And{}
+
+	if search.VehicleType != "" {
+		conditions = append(conditions, sq.Eq{"r.vehicle_type": search.VehicleType})
+	}
+
+	if search.RouteType != "" {
+		conditions = append(conditions, sq.Eq{"r.route_type": search.RouteType})
+	}
+
+	if search.RouteID != nil {
+		conditions = append(conditions, sq.Eq{"wr.route_id": *search.RouteID})
+	}
+
+	if search.RouteKind != nil {
+		conditions = append(conditions, sq.Eq{"wr.route_kind": *search.RouteKind})
+	}
+
+	return conditions
+}
+
+// ListWithin возвращает точки внутри области area, упорядоченные по id.
+// Невалидная геометрия (например, самопересекающийся полигон) возвращает ErrBadRequest.
+func (r *waypointRepo) ListWithin(ctx context.Context, area domain.Area, filter domain.WaypointFilter) ([]domain.Waypoint, error) {
+	geojson, err := json.Marshal(map[string]any{
+		"type":        "MultiPolygon",
+		"coordinates": area,
+	})
+	if err != nil {
+
+		return nil, err
+	}
+
+	var valid bool
+	if err := r.db.QueryRow(ctx, "SELECT ST_IsValid(ST_GeomFromGeoJSON($1))", string(geojson)).Scan(&valid); err != nil {
+
+		return nil, err
+	}
+
+	if !valid {
+		return nil, fmt.Errorf("%w, invalid polygon geometry", domain.ErrBadRequest)
+	}
+
+	selectBuilder := sq.Select(waypointColumns...).
 		From(waypointTable).
-		Limit(limit).
-		Offset(offset).
+		Where(sq.Expr("ST_Intersects(geom, ST_SetSRID(ST_GeomFromGeoJSON(?), 4326))", string(geojson))).
+		Where(waypointFilterToSql(filter)).
+		Where(visible(ctx, "")).
+		OrderBy("id").
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := selectBuilder.ToSql()
@@ -104,8 +493,8 @@ func (r *waypointRepo) List(ctx context.Context, limit, offset uint64) ([]domain
 	defer rows.Close()
 
 	for rows.Next() {
-		var waypoint domain.Waypoint
-		if err := rows.Scan(&waypoint.ID, &waypoint.Name, &waypoint.Latitude, &waypoint.Longitude); err != nil {
+		waypoint, err := scanWaypoint(rows)
+		if err != nil {
 
 			return nil, err
 		}
@@ -120,36 +509,246 @@ func (r *waypointRepo) List(ctx context.Context, limit, offset uint64) ([]domain
 	return waypoints, nil
 }
 
-func (r *waypointRepo) GetOfNearest(ctx context.Context, amount int, latitude, longitude float64) ([]domain.Waypoint, error) {
+// GetOpposite ищет ближайшую к waypoint остановку в радиусе radius метров, не входящую в exclude.
+// Остановки с тем же названием предпочтительнее, так как обычно это пара на противоположной стороне дороги.
+func (r *waypointRepo) GetOpposite(ctx context.Context, waypoint domain.Waypoint, radius float64, exclude []uuid.UUID) (domain.Waypoint, error) {
 	query := `
     SELECT id, name, latitude, longitude
     FROM waypoints
-    ORDER BY ST_DistanceSphere(
+    WHERE id <> ALL($4)
+      AND deleted_at IS NULL
+      AND ST_DWithin(
+        geom::geography,
+        ST_SetSRID(ST_MakePoint($1, $2), 4326)::geography,
+        $5
+      )
+    ORDER BY name = $3 DESC, ST_DistanceSphere(
         geom,
         ST_SetSRID(ST_MakePoint($1, $2), 4326)
     )
-    LIMIT $3;
+    LIMIT 1;
 	`
 
-	var waypoints []domain.Waypoint
+	if exclude == nil {
+		exclude = []uuid.UUID{}
+	}
 
-	rows, err := r.db.Query(ctx, query, longitude, latitude, amount)
+	var opposite domain.Waypoint
+	err := r.db.QueryRow(ctx, query, waypoint.Longitude, waypoint.Latitude, waypoint.Name, exclude, radius).
+		Scan(&opposite.ID, &opposite.Name, &opposite.Latitude, &opposite.Longitude)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Waypoint{}, fmt.Errorf("%w, %s", domain.ErrNotFound, err)
+		}
+
+		return domain.Waypoint{}, err
+	}
+
+	return opposite, nil
+}
+
+// Вес близости к заданным координатам в итоговой оценке поиска.
+// Точка в 0 м получает +searchDistanceWeight, в 1 км - половину.
+const searchDistanceWeight = 0.3
+
+// Search ищет точки по названию с помощью pg_trgm: по исходному названию и по его транслитерации.
+func (r *waypointRepo) Search(ctx context.Context, search domain.WaypointSearch) ([]domain.WaypointMatch, error) {
+	q := strings.ToLower(strings.TrimSpace(search.Query))
+	qLatin := i18n.Transliterate(q)
+
+	query := `
+    SELECT id, name, latitude, longitude,
+      wheelchair_boarding, shelter, bench, lighting, tactile_paving, platform_code, description,
+      valid_from, valid_to,
+      score, distance
+    FROM (
+      SELECT *,
+        GREATEST(word_similarity($1, lower(name)), word_similarity($2, name_latin))
+          + COALESCE($5 / (1 + distance / 1000), 0) AS score
+      FROM (
+        SELECT *,
+          CASE WHEN $3::float8 IS NULL OR $4::float8 IS NULL THEN NULL
+          ELSE ST_DistanceSphere(geom, ST_SetSRID(ST_MakePoint($3, $4), 4326))
+          END AS distance
+        FROM waypoints
+        WHERE ($1 <% lower(name)
+          OR $2 <% name_latin
+          OR lower(name) LIKE '%' || $6 || '%'
+          OR name_latin LIKE '%' || $7 || '%')
+          AND ` + visibleSql("waypoints", 9) + `
+      ) candidates
+    ) ranked
+    ORDER BY score DESC, name
+    LIMIT $8;
+	`
+
+	rows, err := r.db.Query(ctx, query,
+		q, qLatin, search.Longitude, search.Latitude, searchDistanceWeight,
+		escapeLike(q), escapeLike(qLatin), search.Limit, validAtArg(ctx),
+	)
 	if err != nil {
 
 		return nil, err
 	}
 	defer rows.Close()
 
+	var matches []domain.WaypointMatch
 	for rows.Next() {
-		var waypoint domain.Waypoint
-		if err := rows.Scan(&waypoint.ID, &waypoint.Name, &waypoint.Latitude, &waypoint.Longitude); err != nil {
+		var match domain.WaypointMatch
+		if err := rows.Scan(append(waypointDest(&match.Waypoint), &match.Score, &match.Distance)...); err != nil {
 
 			return nil, err
 		}
-		waypoints = append(waypoints, waypoint)
+		matches = append(matches, match)
 	}
 
-	return waypoints, nil
+	return matches, rows.Err()
+}
+
+// SimilarPairs ищет пары остановок в радиусе params.Radius метров друг от друга с похожими названиями.
+func (r *waypointRepo) SimilarPairs(ctx context.Context, params domain.SimilarityParams) ([]domain.WaypointPair, error) {
+	selectBuilder := sq.Select(prefixedWaypointColumns("a")...).
+		Columns(prefixedWaypointColumns("b")...).
+		Column("ST_Distance(a.geom::geography, b.geom::geography) AS distance").
+		Column("similarity(lower(a.name), lower(b.name))").
+		From(waypointTable+" a").
+		Join(waypointTable+" b ON a.id < b.id AND ST_DWithin(a.geom::geography, b.geom::geography, ?)", params.Radius).
+		Where("similarity(lower(a.name), lower(b.name)) >= ?", params.MinSimilarity).
+		Where("a.deleted_at IS NULL AND b.deleted_at IS NULL").
+		OrderBy("distance").
+		PlaceholderFormat(sq.Dollar)
+
+	if params.WithoutStation {
+		selectBuilder = selectBuilder.Where("a.station_id IS NULL AND b.station_id IS NULL")
+	}
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+
+		return nil, err
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pairs []domain.WaypointPair
+	for rows.Next() {
+		var pair domain.WaypointPair
+
+		dest := append(waypointDest(&pair.First), waypointDest(&pair.Second)...)
+		if err := rows.Scan(append(dest, &pair.Distance, &pair.Similarity)...); err != nil {
+
+			return nil, err
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return pairs, rows.Err()
+}
+
+// Merge объединяет остановку mergedID с survivorID в одной транзакции:
+// ссылки маршрутов, переводы и станция переносятся на survivorID, mergedID удаляется и остается псевдонимом.
+// Если маршрут проходит через обе остановки, позиция mergedID удаляется из маршрута.
+func (r *waypointRepo) Merge(ctx context.Context, survivorID, mergedID uuid.UUID) error {
+	return runWithTx(ctx, r.db, func(ctx context.Context, tx pgx.Tx) error {
+		var mergedName string
+		if err := tx.QueryRow(ctx, "SELECT name FROM waypoints WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", mergedID).Scan(&mergedName); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return fmt.Errorf("%w, waypoint %s not found", domain.ErrNotFound, mergedID)
+			}
+
+			return err
+		}
+
+		var exists bool
+		if err := tx.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM waypoints WHERE id = $1 AND deleted_at IS NULL)", survivorID).Scan(&exists); err != nil {
+
+			return err
+		}
+
+		if !exists {
+			return fmt.Errorf("%w, waypoint %s not found", domain.ErrNotFound, survivorID)
+		}
+
+		rows, err := tx.Query(ctx, `
+    SELECT route_id, pattern_id FROM waypoint_routes
+    WHERE waypoint_id = $1 AND deleted_at IS NULL
+      AND pattern_id IN (SELECT pattern_id FROM waypoint_routes WHERE waypoint_id = $2 AND deleted_at IS NULL);
+		`, mergedID, survivorID)
+		if err != nil {
+
+			return err
+		}
+
+		shared, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.WaypointRoute, error) {
+			var wr domain.WaypointRoute
+			err := row.Scan(&wr.RouteID, &wr.PatternID)
+
+			return wr, err
+		})
+		if err != nil {
+
+			return err
+		}
+
+		for _, wr := range shared {
+			if err := detachWaypoint(ctx, tx, wr.RouteID, wr.PatternID, mergedID); err != nil {
+
+				return err
+			}
+		}
+
+		statements := []string{
+			// Скрытые остановки удаленных маршрутов, через которые проходят обе точки, не переносятся
+			`DELETE FROM waypoint_routes
+        WHERE waypoint_id = $2
+          AND pattern_id IN (SELECT pattern_id FROM waypoint_routes WHERE waypoint_id = $1)`,
+			"UPDATE waypoint_routes SET waypoint_id = $1 WHERE waypoint_id = $2",
+			`INSERT INTO waypoint_translations (waypoint_id, lang, name)
+        SELECT $1, lang, name FROM waypoint_translations WHERE waypoint_id = $2
+        ON CONFLICT DO NOTHING`,
+			"UPDATE waypoints SET station_id = COALESCE(station_id, (SELECT station_id FROM waypoints WHERE id = $2)) WHERE id = $1",
+			"UPDATE waypoint_aliases SET waypoint_id = $1 WHERE waypoint_id = $2",
+		}
+
+		for _, statement := range statements {
+			if _, err := tx.Exec(ctx, statement, survivorID, mergedID); err != nil {
+
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(ctx, "INSERT INTO waypoint_aliases (alias_id, waypoint_id, name) VALUES ($1, $2, $3)", mergedID, survivorID, mergedName); err != nil {
+
+			return err
+		}
+
+		_, err = tx.Exec(ctx, "DELETE FROM waypoints WHERE id = $1", mergedID)
+
+		return err
+	})
+}
+
+// ResolveAlias возвращает идентификатор остановки, с которой была объединена остановка id.
+func (r *waypointRepo) ResolveAlias(ctx context.Context, id uuid.UUID) (uuid.UUID, error) {
+	var wID uuid.UUID
+	if err := r.db.QueryRow(ctx, "SELECT waypoint_id FROM waypoint_aliases WHERE alias_id = $1", id).Scan(&wID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("%w, %s", domain.ErrNotFound, err)
+		}
+
+		return uuid.Nil, err
+	}
+
+	return wID, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 func (r *waypointRepo) Update(ctx context.Context, waypoint domain.Waypoint) error {
@@ -157,7 +756,17 @@ func (r *waypointRepo) Update(ctx context.Context, waypoint domain.Waypoint) err
 		Set("name", waypoint.Name).
 		Set("latitude", waypoint.Latitude).
 		Set("longitude", waypoint.Longitude).
-		Where(sq.Eq{"id": waypoint.ID}).
+		Set("geom", fmt.Sprintf("SRID=4326;POINT(%f %f)", waypoint.Longitude, waypoint.Latitude)).
+		Set("wheelchair_boarding", waypoint.WheelchairBoarding).
+		Set("shelter", waypoint.Shelter).
+		Set("bench", waypoint.Bench).
+		Set("lighting", waypoint.Lighting).
+		Set("tactile_paving", waypoint.TactilePaving).
+		Set("platform_code", waypoint.PlatformCode).
+		Set("description", waypoint.Description).
+		Set("valid_from", waypoint.ValidFrom).
+		Set("valid_to", waypoint.ValidTo).
+		Where(sq.Eq{"id": waypoint.ID, "deleted_at": nil}).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := updateBuilder.ToSql()
@@ -166,7 +775,7 @@ func (r *waypointRepo) Update(ctx context.Context, waypoint domain.Waypoint) err
 		return err
 	}
 
-	_, err = r.db.Exec(ctx, query, args...)
+	tag, err := r.db.Exec(ctx, query, args...)
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
@@ -183,37 +792,34 @@ func (r *waypointRepo) Update(ctx context.Context, waypoint domain.Waypoint) err
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%w, waypoint %s", domain.ErrNotFound, waypoint.ID)
+	}
+
 	return nil
 }
 
+// Delete помечает точку удаленной вместе с ее остановками в маршрутах. Восстановить ее можно через Restore.
 func (r *waypointRepo) Delete(ctx context.Context, id uuid.UUID) error {
-	deleteBuilder := sq.Delete(waypointTable).
-		Where(sq.Eq{"id": id}).
-		PlaceholderFormat(sq.Dollar)
-
-	query, args, err := deleteBuilder.ToSql()
-	if err != nil {
-
-		return err
-	}
-
-	_, err = r.db.Exec(ctx, query, args...)
-
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return fmt.Errorf("%w, %s", domain.ErrNotFound, err)
-		}
+	return softDelete(ctx, r.db, waypointTable, waypointLink, id)
+}
 
-		return err
-	}
+// Restore восстанавливает удаленную точку вместе с остановками, удаленными вместе с ней.
+func (r *waypointRepo) Restore(ctx context.Context, id uuid.UUID) error {
+	return restore(ctx, r.db, waypointTable, waypointLink, routesTable, routeLink, id, reinsertWaypointStops)
+}
 
-	return nil
+// Purge окончательно удаляет точки, удаленные раньше before.
+func (r *waypointRepo) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return purge(ctx, r.db, waypointTable, before)
 }
 
 func (r *waypointRepo) ListRoutes(ctx context.Context, wID uuid.UUID) ([]domain.WaypointRoute, error) {
-	selectBuilder := sq.Select("route_id", "route_name", "route_kind", "route_number").
+	selectBuilder := sq.Select("route_id", "pattern_id", "route_name", "route_kind", "route_number", "pickup_type", "drop_off_type",
+		"valid_from", "valid_to").
 		From(waypointRoutesTable).
 		Where(sq.Eq{"waypoint_id": wID}).
+		Where(visible(ctx, "")).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := selectBuilder.ToSql()
@@ -236,7 +842,8 @@ func (r *waypointRepo) ListRoutes(ctx context.Context, wID uuid.UUID) ([]domain.
 	for rows.Next() {
 		var route domain.WaypointRoute
 		route.WaypointID = wID
-		if err := rows.Scan(&route.RouteID, &route.RouteName, &route.RouteKind, &route.RouteNumber); err != nil {
+		if err := rows.Scan(&route.RouteID, &route.PatternID, &route.RouteName, &route.RouteKind, &route.RouteNumber, &route.PickupType, &route.DropOffType,
+			&route.ValidFrom, &route.ValidTo); err != nil {
 
 			return nil, err
 		}
@@ -246,10 +853,16 @@ func (r *waypointRepo) ListRoutes(ctx context.Context, wID uuid.UUID) ([]domain.
 	return routes, nil
 }
 
-func (r *waypointRepo) WaypointRoutes(ctx context.Context, wID uuid.UUID) ([]domain.WaypointRoute, error) {
-	selectBuilder := sq.Select("route_id", "route_number").
-		From(waypointRoutesTable).
-		Where(sq.Eq{"waypoint_id": wID}).
+// WaypointRoutes возвращает позиции точек wIDs во всех вариантах всех маршрутов.
+// Если в контексте задана дата, не действующие на нее точки и остановки в маршрутах пропускаются.
+func (r *waypointRepo) WaypointRoutes(ctx context.Context, wIDs ...uuid.UUID) ([]domain.WaypointRoute, error) {
+	selectBuilder := sq.Select("wr.waypoint_id", "wr.route_id", "wr.pattern_id", "wr.route_number", "wr.pickup_type", "wr.drop_off_type",
+		"wr.valid_from", "wr.valid_to").
+		From(waypointRoutesTable + " wr").
+		Join(waypointTable + " w ON w.id = wr.waypoint_id").
+		Where(sq.Eq{"wr.waypoint_id": wIDs}).
+		Where(visible(ctx, "wr")).
+		Where(visible(ctx, "w")).
 		PlaceholderFormat(sq.Dollar)
 
 	query, args, err := selectBuilder.ToSql()
@@ -271,7 +884,8 @@ func (r *waypointRepo) WaypointRoutes(ctx context.Context, wID uuid.UUID) ([]dom
 
 	for rows.Next() {
 		var route domain.WaypointRoute
-		if err := rows.Scan(&route.RouteID, &route.RouteNumber); err != nil {
+		if err := rows.Scan(&route.WaypointID, &route.RouteID, &route.PatternID, &route.RouteNumber, &route.PickupType, &route.DropOffType,
+			&route.ValidFrom, &route.ValidTo); err != nil {
 
 			return nil, err
 		}
@@ -280,3 +894,70 @@ func (r *waypointRepo) WaypointRoutes(ctx context.Context, wID uuid.UUID) ([]dom
 
 	return routes, nil
 }
+
+func scanWaypoint(row pgx.Row) (domain.Waypoint, error) {
+	var waypoint domain.Waypoint
+
+	err := row.Scan(waypointDest(&waypoint)...)
+
+	return waypoint, err
+}
+
+// waypointDest возвращает указатели на поля точки в порядке waypointColumns,
+// чтобы сканировать точку вместе с дополнительными колонками.
+func waypointDest(waypoint *domain.Waypoint) []any {
+	return []any{
+		&waypoint.ID, &waypoint.Name, &waypoint.Latitude, &waypoint.Longitude,
+		&waypoint.WheelchairBoarding, &waypoint.Shelter, &waypoint.Bench, &waypoint.Lighting, &waypoint.TactilePaving,
+		&waypoint.PlatformCode, &waypoint.Description,
+		&waypoint.ValidFrom, &waypoint.ValidTo,
+	}
+}
+
+// prefixedWaypointColumns возвращает waypointColumns с псевдонимом таблицы.
+func prefixedWaypointColumns(alias string) []string {
+	columns := make([]string, len(waypointColumns))
+	for i, column := range waypointColumns {
+		columns[i] = alias + "." + column
+	}
+
+	return columns
+}
+
+func waypointFilterToSql(filter domain.WaypointFilter) sq.And {
+	conditions := sq.And{}
+
+	if filter.WheelchairBoarding != "" {
+		conditions = append(conditions, sq.Eq{"wheelchair_boarding": filter.WheelchairBoarding})
+	}
+
+	if filter.Shelter != nil {
+		conditions = append(conditions, sq.Eq{"shelter": *filter.Shelter})
+	}
+
+	if filter.Bench != nil {
+		conditions = append(conditions, sq.Eq{"bench": *filter.Bench})
+	}
+
+	if filter.Lighting != nil {
+		conditions = append(conditions, sq.Eq{"lighting": *filter.Lighting})
+	}
+
+	if filter.TactilePaving != nil {
+		conditions = append(conditions, sq.Eq{"tactile_paving": *filter.TactilePaving})
+	}
+
+	if filter.PlatformCode != "" {
+		conditions = append(conditions, sq.Eq{"platform_code": filter.PlatformCode})
+	}
+
+	if filter.BBox != nil {
+		// && использует GIST-индекс по geom.
+		conditions = append(conditions, sq.Expr(
+			"geom && ST_MakeEnvelope(?, ?, ?, ?, 4326)",
+			filter.BBox.MinLon, filter.BBox.MinLat, filter.BBox.MaxLon, filter.BBox.MaxLat,
+		))
+	}
+
+	return conditions
+}
@@ -0,0 +1,201 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+)
+
+// auditor записывает изменения остановок и маршрутов в журнал вместе со снимками до и после изменения.
+type auditor struct {
+	repo  domain.AuditRepository
+	wRepo domain.WaypointsRepository
+	rRepo domain.RoutesRepository
+}
+
+// auditEntry описывает изменяемый объект. Для изменений списка остановок WaypointID и PatternID
+// указывают остановку в варианте маршрута EntityID (uuid.Nil - основной вариант).
+// Для изменений варианта маршрута указывается только PatternID.
+type auditEntry struct {
+	EntityType string
+	EntityID   uuid.UUID
+	Action     string
+	WaypointID uuid.UUID
+	PatternID  uuid.UUID
+}
+
+// auditEntryOf определяет объект изменения по виду изменения черновика и его данным (см. changeTarget).
+// Для изменений остановок в маршруте stop указывает остановку, для изменений вариантов - только PatternID.
+func auditEntryOf(op string, entityID uuid.UUID, stop domain.WaypointRoute) auditEntry {
+	switch op {
+	case domain.DraftWaypointCreate, domain.DraftWaypointUpdate, domain.DraftWaypointDelete:
+		return auditEntry{EntityType: domain.AuditWaypoint, EntityID: entityID, Action: op}
+	case domain.DraftRouteCreate, domain.DraftRouteUpdate, domain.DraftRouteDelete:
+		return auditEntry{EntityType: domain.AuditRoute, EntityID: entityID, Action: op}
+	case domain.DraftPatternCreate, domain.DraftPatternUpdate, domain.DraftPatternDelete:
+		return auditEntry{EntityType: domain.AuditRoute, EntityID: entityID, Action: op, PatternID: stop.PatternID}
+	default:
+		return auditEntry{
+			EntityType: domain.AuditRoute,
+			EntityID:   stop.RouteID,
+			Action:     op,
+			WaypointID: stop.WaypointID,
+			PatternID:  stop.PatternID,
+		}
+	}
+}
+
+// changeTarget возвращает остановку или вариант маршрута, которые изменяет изменение с данными payload.
+// Для остальных изменений объект определяется по EntityID, и результат пустой.
+func changeTarget(payload any) domain.WaypointRoute {
+	switch payload := payload.(type) {
+	case domain.WaypointRoute:
+		return payload
+	case draftPattern:
+		return domain.WaypointRoute{RouteID: payload.Pattern.RouteID, PatternID: payload.Pattern.ID}
+	default:
+		return domain.WaypointRoute{}
+	}
+}
+
+// track выполняет apply и записывает изменение в журнал в одной транзакции. Ошибка apply возвращается без обработки.
+// Объект блокируется до снимка, поэтому снимок до изменения не устаревает к моменту apply.
+// Изменения остановок и вариантов блокируют свой маршрут, как и сами изменения.
+func (a auditor) track(ctx context.Context, entry auditEntry, apply func(ctx context.Context) error) error {
+	return a.repo.InTx(ctx, func(ctx context.Context) error {
+		if err := a.repo.Lock(ctx, entry.EntityType, entry.EntityID); err != nil {
+			return err
+		}
+
+		before, err := a.snapshot(ctx, entry)
+		if err != nil {
+			return err
+		}
+
+		if err := apply(ctx); err != nil {
+			return err
+		}
+
+		after, err := a.snapshot(ctx, entry)
+		if err != nil {
+			return err
+		}
+
+		return a.record(ctx, entry, before, after)
+	})
+}
+
+// recordCreated записывает создание объектов ids.
+func (a auditor) recordCreated(ctx context.Context, entityType, action string, ids []uuid.UUID) error {
+	for _, id := range ids {
+		entry := auditEntry{EntityType: entityType, EntityID: id, Action: action}
+
+		after, err := a.snapshot(ctx, entry)
+		if err != nil {
+			return err
+		}
+
+		if err := a.record(ctx, entry, nil, after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (a auditor) record(ctx context.Context, entry auditEntry, before, after json.RawMessage) error {
+	record := domain.AuditRecord{
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Action:     entry.Action,
+		Actor:      domain.ActorFromContext(ctx),
+		RequestID:  domain.RequestIDFromContext(ctx),
+		Before:     before,
+		After:      after,
+	}
+
+	if entry.WaypointID != uuid.Nil {
+		record.WaypointID = &entry.WaypointID
+	}
+
+	return a.repo.Add(ctx, record)
+}
+
+// snapshot возвращает состояние объекта в JSON или nil, если объекта нет.
+func (a auditor) snapshot(ctx context.Context, entry auditEntry) (json.RawMessage, error) {
+	var (
+		value any
+		err   error
+	)
+
+	switch {
+	case entry.WaypointID != uuid.Nil:
+		value, err = a.stop(ctx, entry.EntityID, entry.PatternID, entry.WaypointID)
+	case entry.PatternID != uuid.Nil:
+		value, err = a.pattern(ctx, entry.EntityID, entry.PatternID)
+	case entry.EntityType == domain.AuditWaypoint:
+		value, err = a.wRepo.GetById(ctx, entry.EntityID)
+	default:
+		value, err = a.rRepo.GetById(ctx, entry.EntityID)
+	}
+
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(value)
+}
+
+// stop возвращает остановку wID в варианте pID маршрута rID.
+func (a auditor) stop(ctx context.Context, rID, pID, wID uuid.UUID) (domain.WaypointRoute, error) {
+	pattern, err := a.rRepo.GetPattern(ctx, rID, pID)
+	if err != nil {
+		return domain.WaypointRoute{}, err
+	}
+
+	routes, err := a.wRepo.ListRoutes(ctx, wID)
+	if err != nil {
+		return domain.WaypointRoute{}, err
+	}
+
+	for _, route := range routes {
+		if route.RouteID == rID && route.PatternID == pattern.ID {
+			return route, nil
+		}
+	}
+
+	return domain.WaypointRoute{}, domain.ErrNotFound
+}
+
+// patternSnapshot - состояние варианта маршрута в журнале: вариант и его остановки по порядку.
+type patternSnapshot struct {
+	Pattern   domain.RoutePattern
+	Waypoints []uuid.UUID
+}
+
+// pattern возвращает вариант pID маршрута rID с его остановками.
+func (a auditor) pattern(ctx context.Context, rID, pID uuid.UUID) (patternSnapshot, error) {
+	pattern, err := a.rRepo.GetPattern(ctx, rID, pID)
+	if err != nil {
+		return patternSnapshot{}, err
+	}
+
+	waypoints, err := a.rRepo.RouteWaypoints(ctx, rID, pID)
+	if err != nil {
+		return patternSnapshot{}, err
+	}
+
+	snapshot := patternSnapshot{Pattern: pattern, Waypoints: make([]uuid.UUID, 0, len(waypoints))}
+	for _, waypoint := range waypoints {
+		snapshot.Waypoints = append(snapshot.Waypoints, waypoint.ID)
+	}
+
+	return snapshot, nil
+}
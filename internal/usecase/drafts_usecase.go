@@ -0,0 +1,441 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/google/uuid"
+)
+
+type draftsUsecase struct {
+	repo       domain.DraftsRepository
+	wRepo      domain.WaypointsRepository
+	rRepo      domain.RoutesRepository
+	validation domain.ValidationUsecase
+	audit      auditor
+
+	log logger.Logger
+}
+
+func NewDraftsUsecase(repo domain.DraftsRepository, wRepo domain.WaypointsRepository, rRepo domain.RoutesRepository, aRepo domain.AuditRepository, validation domain.ValidationUsecase, log logger.Logger) domain.DraftsUsecase {
+	return &draftsUsecase{
+		repo:       repo,
+		wRepo:      wRepo,
+		rRepo:      rRepo,
+		validation: validation,
+		audit:      auditor{repo: aRepo, wRepo: wRepo, rRepo: rRepo},
+		log:        log,
+	}
+}
+
+// draftError - ошибка самого черновика, а не добавляемого изменения. Возвращается клиенту как есть.
+type draftError struct {
+	err error
+}
+
+func (e draftError) Error() string {
+	return e.err.Error()
+}
+
+func (e draftError) Unwrap() error {
+	return errors.Unwrap(e.err)
+}
+
+func isDraftError(err error) bool {
+	var dErr draftError
+
+	return errors.As(err, &dErr)
+}
+
+// Данные изменения route_create.
+type draftRouteCreate struct {
+	Route       domain.Route
+	WaypointIds []uuid.UUID
+}
+
+// Данные изменений pattern_create, pattern_update и pattern_delete. Для pattern_update и pattern_delete
+// в Pattern заполнены только ID и RouteID.
+type draftPattern struct {
+	Pattern     domain.RoutePattern
+	WaypointIds []uuid.UUID               // pattern_create
+	Update      domain.RoutePatternUpdate // pattern_update
+}
+
+// stageOrApply выполняет apply с записью в журнал изменений или, если запрос относится к черновику,
+// проверяет изменение поверх черновика и добавляет его туда.
+func stageOrApply(ctx context.Context, drafts domain.DraftsUsecase, audit auditor, op string, entityID uuid.UUID, payload any, apply func(ctx context.Context) error) error {
+	if id, ok := domain.DraftFromContext(ctx); ok {
+		return drafts.Stage(ctx, id, op, entityID, payload)
+	}
+
+	return audit.track(ctx, auditEntryOf(op, entityID, changeTarget(payload)), apply)
+}
+
+func (d *draftsUsecase) List(ctx context.Context, status string) ([]domain.Draft, error) {
+	drafts, err := d.repo.List(ctx, status)
+	if err != nil {
+
+		d.log.Error("list drafts", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	return drafts, nil
+}
+
+func (d *draftsUsecase) GetById(ctx context.Context, id uuid.UUID) (domain.Draft, error) {
+	draft, err := d.repo.GetById(ctx, id)
+	if err != nil {
+
+		d.log.Error("get draft", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Draft{}, fmt.Errorf("%w: draft not found", domain.ErrNotFound)
+		}
+
+		return domain.Draft{}, domain.ErrInternalServerError
+	}
+
+	return draft, nil
+}
+
+func (d *draftsUsecase) Create(ctx context.Context, name string) (domain.Draft, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return domain.Draft{}, err
+	}
+
+	if err := d.repo.Create(ctx, domain.Draft{ID: id, Name: name, Status: domain.DraftOpen}); err != nil {
+
+		d.log.Error("create draft", "error:", err)
+
+		return domain.Draft{}, domain.ErrInternalServerError
+	}
+
+	return d.GetById(ctx, id)
+}
+
+// Stage применяет черновик и новое изменение в откатываемой точке сохранения, чтобы ошибки изменения
+// (нет остановки, дубликат и т.д.) возвращались сразу, а не при публикации. Черновик заблокирован до добавления
+// изменения, поэтому параллельные изменения черновика проверяются друг после друга. Ошибка изменения возвращается без обработки.
+func (d *draftsUsecase) Stage(ctx context.Context, id uuid.UUID, op string, entityID uuid.UUID, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+
+		d.log.Error("stage draft change", "error:", err)
+
+		return domain.ErrInternalServerError
+	}
+
+	change := domain.DraftChange{Op: op, EntityID: entityID, Payload: data}
+
+	err = d.repo.InTx(ctx, true, func(ctx context.Context) error {
+		if err := d.repo.Lock(ctx, id); err != nil {
+
+			d.log.Error("stage draft change", "error:", err)
+
+			return d.draftError(err)
+		}
+
+		draft, err := d.openDraft(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		err = d.repo.InTx(ctx, false, func(ctx context.Context) error {
+			if err := d.replay(ctx, draft.Changes, d.apply); err != nil {
+				return err
+			}
+
+			return d.apply(ctx, change)
+		})
+		if err != nil {
+			return err
+		}
+
+		seq, err := d.repo.AddChange(ctx, id, change)
+		if err != nil {
+
+			d.log.Error("stage draft change", "error:", err)
+
+			return d.draftError(err)
+		}
+
+		d.log.Debug("stage draft change", "draft:", id, "seq:", seq, "op:", op)
+
+		return nil
+	})
+
+	return d.txError(err)
+}
+
+// RemoveChange удаляет изменение seq из черновика. Так из черновика убирают изменение, которое больше не применимо
+// и из-за которого черновик нельзя просмотреть и опубликовать.
+func (d *draftsUsecase) RemoveChange(ctx context.Context, id uuid.UUID, seq int) (domain.Draft, error) {
+	err := d.repo.InTx(ctx, true, func(ctx context.Context) error {
+		if err := d.repo.Lock(ctx, id); err != nil {
+
+			d.log.Error("remove draft change", "error:", err)
+
+			return d.draftError(err)
+		}
+
+		if err := d.repo.RemoveChange(ctx, id, seq); err != nil {
+
+			d.log.Error("remove draft change", "error:", err)
+
+			// Черновик уже заблокирован и открыт, поэтому не найдено само изменение
+			if errors.Is(err, domain.ErrNotFound) {
+				return fmt.Errorf("%w: draft change not found", domain.ErrNotFound)
+			}
+
+			return domain.ErrInternalServerError
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Draft{}, d.txError(err)
+	}
+
+	return d.GetById(ctx, id)
+}
+
+func (d *draftsUsecase) Preview(ctx context.Context, id uuid.UUID, fn func(ctx context.Context) error) error {
+	err := d.repo.InTx(ctx, false, func(ctx context.Context) error {
+		draft, err := d.openDraft(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := d.replay(ctx, draft.Changes, d.apply); err != nil {
+			return err
+		}
+
+		return fn(ctx)
+	})
+
+	return d.txError(err)
+}
+
+// Validate проверяет сеть с примененным черновиком.
+func (d *draftsUsecase) Validate(ctx context.Context, id uuid.UUID, params domain.ValidationParams) (domain.ValidationReport, error) {
+	var report domain.ValidationReport
+
+	err := d.Preview(ctx, id, func(ctx context.Context) error {
+		var err error
+		report, err = d.validation.Validate(ctx, params)
+
+		return err
+	})
+
+	return report, err
+}
+
+// Publish применяет все изменения черновика к сети в одной транзакции.
+// Если какое-то изменение больше не применимо (например, остановку уже удалили), сеть не изменяется.
+func (d *draftsUsecase) Publish(ctx context.Context, id uuid.UUID) (domain.Draft, error) {
+	err := d.repo.InTx(ctx, true, func(ctx context.Context) error {
+		// Сначала закрываем черновик: блокировка не дает опубликовать его дважды параллельно
+		if err := d.repo.SetStatus(ctx, id, domain.DraftPublished); err != nil {
+
+			d.log.Error("publish draft", "error:", err)
+
+			return d.draftError(err)
+		}
+
+		draft, err := d.repo.GetById(ctx, id)
+		if err != nil {
+
+			d.log.Error("publish draft", "error:", err)
+
+			return domain.ErrInternalServerError
+		}
+
+		// Опубликованные изменения попадают в журнал изменений, как и сделанные напрямую
+		return d.replay(ctx, draft.Changes, d.applyAudited)
+	})
+	if err != nil {
+		return domain.Draft{}, d.txError(err)
+	}
+
+	return d.GetById(ctx, id)
+}
+
+func (d *draftsUsecase) Discard(ctx context.Context, id uuid.UUID) (domain.Draft, error) {
+	if err := d.repo.SetStatus(ctx, id, domain.DraftDiscarded); err != nil {
+
+		d.log.Error("discard draft", "error:", err)
+
+		return domain.Draft{}, d.draftError(err)
+	}
+
+	return d.GetById(ctx, id)
+}
+
+// openDraft возвращает черновик, если его еще можно изменять.
+func (d *draftsUsecase) openDraft(ctx context.Context, id uuid.UUID) (domain.Draft, error) {
+	draft, err := d.repo.GetById(ctx, id)
+	if err != nil {
+
+		d.log.Error("get draft", "error:", err)
+
+		return domain.Draft{}, d.draftError(err)
+	}
+
+	if draft.Status != domain.DraftOpen {
+		return domain.Draft{}, draftError{fmt.Errorf("%w: draft is %s", domain.ErrConflict, draft.Status)}
+	}
+
+	return draft, nil
+}
+
+// replay применяет изменения черновика по порядку с помощью apply.
+func (d *draftsUsecase) replay(ctx context.Context, changes []domain.DraftChange, apply func(ctx context.Context, change domain.DraftChange) error) error {
+	for _, change := range changes {
+		if err := apply(ctx, change); err != nil {
+
+			d.log.Error("replay draft", "seq:", change.Seq, "op:", change.Op, "error:", err)
+
+			if errors.Is(err, domain.ErrNotFound) || errors.Is(err, domain.ErrConflict) || errors.Is(err, domain.ErrBadRequest) {
+				return draftError{fmt.Errorf("%w: draft change %d (%s) no longer applies, remove it from the draft", domain.ErrConflict, change.Seq, change.Op)}
+			}
+
+			// Исходная ошибка сохраняется, чтобы транзакция распознала ожидание блокировки (см. txError)
+			return fmt.Errorf("%w: draft change %d: %w", domain.ErrInternalServerError, change.Seq, err)
+		}
+	}
+
+	return nil
+}
+
+// txError переводит ошибку транзакции черновика в ошибку для клиента. Применение черновика изменяет строки сети,
+// поэтому транзакция может не дождаться строк, занятых параллельными изменениями, - такой запрос можно повторить.
+func (d *draftsUsecase) txError(err error) error {
+	switch {
+	case err == nil, isDraftError(err):
+		return err
+	case errors.Is(err, domain.ErrUnavailable):
+		return draftError{fmt.Errorf("%w: network is locked by concurrent changes, retry later", domain.ErrUnavailable)}
+	case errors.Is(err, domain.ErrInternalServerError):
+		return domain.ErrInternalServerError
+	default:
+		return err
+	}
+}
+
+// draftError переводит ошибку репозитория черновиков в ошибку для клиента.
+func (d *draftsUsecase) draftError(err error) error {
+	if errors.Is(err, domain.ErrNotFound) {
+		return draftError{fmt.Errorf("%w: draft not found", domain.ErrNotFound)}
+	}
+
+	if errors.Is(err, domain.ErrConflict) {
+		return draftError{fmt.Errorf("%w: draft is not open", domain.ErrConflict)}
+	}
+
+	return domain.ErrInternalServerError
+}
+
+func decodeChange[T any](change domain.DraftChange) (T, error) {
+	var payload T
+	err := json.Unmarshal(change.Payload, &payload)
+
+	return payload, err
+}
+
+// applyAudited применяет изменение черновика с записью в журнал изменений.
+func (d *draftsUsecase) applyAudited(ctx context.Context, change domain.DraftChange) error {
+	var (
+		payload any
+		err     error
+	)
+
+	switch change.Op {
+	case domain.DraftStopAttach, domain.DraftStopDetach, domain.DraftStopMove, domain.DraftStopRules, domain.DraftStopValidity:
+		payload, err = decodeChange[domain.WaypointRoute](change)
+	case domain.DraftPatternCreate, domain.DraftPatternUpdate, domain.DraftPatternDelete:
+		payload, err = decodeChange[draftPattern](change)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return d.audit.track(ctx, auditEntryOf(change.Op, change.EntityID, changeTarget(payload)), func(ctx context.Context) error {
+		return d.apply(ctx, change)
+	})
+}
+
+// apply применяет одно изменение черновика через репозитории.
+func (d *draftsUsecase) apply(ctx context.Context, change domain.DraftChange) error {
+	switch change.Op {
+	case domain.DraftWaypointCreate, domain.DraftWaypointUpdate:
+		waypoint, err := decodeChange[domain.Waypoint](change)
+		if err != nil {
+			return err
+		}
+
+		if change.Op == domain.DraftWaypointCreate {
+			return d.wRepo.Create(ctx, waypoint)
+		}
+
+		return d.wRepo.Update(ctx, waypoint)
+	case domain.DraftWaypointDelete:
+		return d.wRepo.Delete(ctx, change.EntityID)
+	case domain.DraftRouteCreate:
+		create, err := decodeChange[draftRouteCreate](change)
+		if err != nil {
+			return err
+		}
+
+		return d.rRepo.Create(ctx, create.Route, create.WaypointIds)
+	case domain.DraftRouteUpdate:
+		update, err := decodeChange[domain.RouteUpdate](change)
+		if err != nil {
+			return err
+		}
+
+		return d.rRepo.Update(ctx, change.EntityID, update)
+	case domain.DraftRouteDelete:
+		return d.rRepo.Delete(ctx, change.EntityID)
+	case domain.DraftPatternCreate, domain.DraftPatternUpdate, domain.DraftPatternDelete:
+		pattern, err := decodeChange[draftPattern](change)
+		if err != nil {
+			return err
+		}
+
+		switch change.Op {
+		case domain.DraftPatternCreate:
+			return d.rRepo.CreatePattern(ctx, pattern.Pattern, pattern.WaypointIds)
+		case domain.DraftPatternUpdate:
+			return d.rRepo.UpdatePattern(ctx, change.EntityID, pattern.Pattern.ID, pattern.Update)
+		default:
+			return d.rRepo.DeletePattern(ctx, change.EntityID, pattern.Pattern.ID)
+		}
+	}
+
+	wr, err := decodeChange[domain.WaypointRoute](change)
+	if err != nil {
+		return err
+	}
+
+	switch change.Op {
+	case domain.DraftStopAttach:
+		return d.rRepo.AttachWaypoint(ctx, wr)
+	case domain.DraftStopDetach:
+		return d.rRepo.DetachWaypoint(ctx, wr.RouteID, wr.PatternID, wr.WaypointID)
+	case domain.DraftStopMove:
+		return d.rRepo.MoveWaypoint(ctx, wr.RouteID, wr.PatternID, wr.WaypointID, wr.RouteNumber)
+	case domain.DraftStopRules:
+		return d.rRepo.SetStopRules(ctx, wr.RouteID, wr.PatternID, wr.WaypointID, wr.PickupType, wr.DropOffType)
+	case domain.DraftStopValidity:
+		return d.rRepo.SetStopValidity(ctx, wr.RouteID, wr.PatternID, wr.WaypointID, wr.Validity)
+	default:
+		return fmt.Errorf("unknown draft change %s", change.Op)
+	}
+}
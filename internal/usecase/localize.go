@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/i18n"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/google/uuid"
+)
+
+type translationsGetter interface {
+	Translations(ctx context.Context, lang string, ids ...uuid.UUID) (map[uuid.UUID]string, error)
+}
+
+// localizeNames подставляет названия на языке из контекста.
+// Если перевода нет, для языков на латинице используется транслитерация исходного названия.
+// Ошибка получения переводов не прерывает запрос: остаются исходные названия.
+func localizeNames(ctx context.Context, repo translationsGetter, log logger.Logger, ids []uuid.UUID, names []*string) {
+	lang := i18n.LangFromContext(ctx)
+	if lang == i18n.SourceLang || len(ids) == 0 {
+		return
+	}
+
+	translated, err := repo.Translations(ctx, lang, ids...)
+	if err != nil {
+		log.Warn("localize names", "lang:", lang, "error:", err)
+		return
+	}
+
+	for i, id := range ids {
+		if name, ok := translated[id]; ok {
+			*names[i] = name
+			continue
+		}
+
+		if i18n.IsLatinLang(lang) {
+			*names[i] = i18n.Transliterate(*names[i])
+		}
+	}
+}
+
+func localizeWaypoints(ctx context.Context, repo translationsGetter, log logger.Logger, waypoints ...*domain.Waypoint) {
+	ids := make([]uuid.UUID, len(waypoints))
+	names := make([]*string, len(waypoints))
+
+	for i, wp := range waypoints {
+		ids[i] = wp.ID
+		names[i] = &wp.Name
+	}
+
+	localizeNames(ctx, repo, log, ids, names)
+}
+
+func localizeWaypointsSlice(ctx context.Context, repo translationsGetter, log logger.Logger, waypoints []domain.Waypoint) {
+	ptrs := make([]*domain.Waypoint, len(waypoints))
+	for i := range waypoints {
+		ptrs[i] = &waypoints[i]
+	}
+
+	localizeWaypoints(ctx, repo, log, ptrs...)
+}
+
+func localizeRoutes(ctx context.Context, repo translationsGetter, log logger.Logger, routes []domain.Route) {
+	ids := make([]uuid.UUID, len(routes))
+	names := make([]*string, len(routes))
+
+	for i := range routes {
+		ids[i] = routes[i].ID
+		names[i] = &routes[i].Name
+	}
+
+	localizeNames(ctx, repo, log, ids, names)
+}
+
+func localizeWaypointRoutes(ctx context.Context, repo translationsGetter, log logger.Logger, routes []domain.WaypointRoute) {
+	ids := make([]uuid.UUID, len(routes))
+	names := make([]*string, len(routes))
+
+	for i := range routes {
+		ids[i] = routes[i].RouteID
+		names[i] = &routes[i].RouteName
+	}
+
+	localizeNames(ctx, repo, log, ids, names)
+}
+
+// localizeNearbyWaypoints переводит названия точек и маршрутов через них двумя запросами.
+func localizeNearbyWaypoints(ctx context.Context, wRepo, rRepo translationsGetter, log logger.Logger, waypoints []domain.NearbyWaypoint) {
+	var (
+		ptrs       = make([]*domain.Waypoint, len(waypoints))
+		routeIds   []uuid.UUID
+		routeNames []*string
+	)
+
+	for i := range waypoints {
+		ptrs[i] = &waypoints[i].Waypoint
+
+		for j := range waypoints[i].Routes {
+			routeIds = append(routeIds, waypoints[i].Routes[j].ID)
+			routeNames = append(routeNames, &waypoints[i].Routes[j].Name)
+		}
+	}
+
+	localizeWaypoints(ctx, wRepo, log, ptrs...)
+	localizeNames(ctx, rRepo, log, routeIds, routeNames)
+}
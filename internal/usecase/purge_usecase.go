@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+)
+
+type purgeUsecase struct {
+	wRepo domain.WaypointsRepository
+	rRepo domain.RoutesRepository
+
+	log logger.Logger
+}
+
+func NewPurgeUsecase(wRepo domain.WaypointsRepository, rRepo domain.RoutesRepository, log logger.Logger) domain.PurgeUsecase {
+	return &purgeUsecase{
+		wRepo: wRepo,
+		rRepo: rRepo,
+		log:   log,
+	}
+}
+
+// Purge сначала удаляет маршруты, затем остановки. Остановки маршрутов удаляются каскадно вместе с ними.
+func (p *purgeUsecase) Purge(ctx context.Context, before time.Time) (domain.PurgeResult, error) {
+	var (
+		result domain.PurgeResult
+		err    error
+	)
+
+	if result.Routes, err = p.rRepo.Purge(ctx, before); err != nil {
+
+		p.log.Error("purge routes", "error:", err)
+
+		return domain.PurgeResult{}, domain.ErrInternalServerError
+	}
+
+	if result.Waypoints, err = p.wRepo.Purge(ctx, before); err != nil {
+
+		p.log.Error("purge waypoints", "error:", err)
+
+		return result, domain.ErrInternalServerError
+	}
+
+	p.log.Info("purge deleted", "before:", before, "routes:", result.Routes, "waypoints:", result.Waypoints)
+
+	return result, nil
+}
@@ -11,30 +11,113 @@ import (
 )
 
 type routesUsecase struct {
-	repo domain.RoutesRepository
-	log  logger.Logger
+	repo   domain.RoutesRepository
+	wRepo  domain.WaypointsRepository
+	vtRepo domain.TypesRepository // Справочник типов транспорта
+	rtRepo domain.TypesRepository // Справочник типов маршрутов
+	drafts domain.DraftsUsecase
+	audit  auditor
+	log    logger.Logger
 }
 
-func NewRoutesUsecase(repo domain.RoutesRepository, log logger.Logger) domain.RoutesUsecase {
+func NewRoutesUsecase(repo domain.RoutesRepository, wRepo domain.WaypointsRepository, vtRepo, rtRepo domain.TypesRepository, aRepo domain.AuditRepository, drafts domain.DraftsUsecase, log logger.Logger) domain.RoutesUsecase {
 	return &routesUsecase{
-		repo: repo,
-		log:  log,
+		repo:   repo,
+		wRepo:  wRepo,
+		vtRepo: vtRepo,
+		rtRepo: rtRepo,
+		drafts: drafts,
+		audit:  auditor{repo: aRepo, wRepo: wRepo, rRepo: repo},
+		log:    log,
 	}
 }
 
-func (r *routesUsecase) List(ctx context.Context, limit, offset uint64) ([]domain.Route, error) {
-	routes, err := r.repo.List(ctx, limit, offset)
+func (r *routesUsecase) List(ctx context.Context, page domain.Page, filter domain.RouteFilter) (domain.RoutesPage, error) {
+	routes, err := r.repo.List(ctx, page, filter)
 	if err != nil {
+		if errors.Is(err, domain.ErrBadRequest) {
+			return domain.RoutesPage{}, fmt.Errorf("%w: invalid cursor", domain.ErrBadRequest)
+		}
 
 		r.log.Error("list routes", "error:", err)
 
-		return nil, domain.ErrInternalServerError
+		return domain.RoutesPage{}, domain.ErrInternalServerError
 	}
 
+	localizeRoutes(ctx, r.repo, r.log, routes.Routes)
+
 	return routes, nil
 }
 
-func (r *routesUsecase) GetById(ctx context.Context, id uuid.UUID) (domain.Route, []domain.Waypoint, error) {
+// Near группирует направления маршрутов рядом с точкой по названию маршрута.
+// Порядок групп - по расстоянию до ближайшей остановки.
+func (r *routesUsecase) Near(ctx context.Context, latitude, longitude, radius float64) ([]domain.NearRoute, error) {
+	directions, err := r.repo.Near(ctx, latitude, longitude, radius)
+	if err != nil {
+
+		r.log.Error("list routes near", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	routes := make([]domain.Route, len(directions))
+	waypoints := make([]*domain.Waypoint, len(directions))
+	for i := range directions {
+		routes[i] = directions[i].Route
+		waypoints[i] = &directions[i].Waypoint
+	}
+
+	localizeRoutes(ctx, r.repo, r.log, routes)
+	localizeWaypoints(ctx, r.wRepo, r.log, waypoints...)
+
+	// Направления одного маршрута совпадают названием и типами: автобус "5" и трамвай "5" - разные маршруты.
+	type routeKey struct {
+		name, vehicleType, routeType string
+	}
+
+	var (
+		near  []domain.NearRoute
+		index = make(map[routeKey]int)
+	)
+
+	// Группировка по исходному названию, т.к. перевод может совпасть у разных маршрутов.
+	for i, direction := range directions {
+		key := routeKey{direction.Route.Name, direction.Route.VehicleType, direction.Route.RouteType}
+		direction.Route = routes[i]
+
+		j, ok := index[key]
+		if !ok {
+			// directions отсортированы по расстоянию, первое направление группы - ближайшее.
+			index[key] = len(near)
+			near = append(near, domain.NearRoute{
+				Name:     routes[i].Name,
+				Distance: direction.Distance,
+			})
+			j = len(near) - 1
+		}
+
+		near[j].Directions = append(near[j].Directions, direction)
+	}
+
+	return near, nil
+}
+
+func (r *routesUsecase) GetById(ctx context.Context, id, pID uuid.UUID) (domain.Route, []domain.Waypoint, error) {
+	route, waypoints, err := r.getWithWaypoints(ctx, id, pID)
+	if err != nil {
+		return domain.Route{}, nil, err
+	}
+
+	routes := []domain.Route{route}
+
+	localizeRoutes(ctx, r.repo, r.log, routes)
+	localizeWaypointsSlice(ctx, r.wRepo, r.log, waypoints)
+
+	return routes[0], waypoints, nil
+}
+
+// getWithWaypoints возвращает маршрут и остановки его варианта pID без перевода названий.
+func (r *routesUsecase) getWithWaypoints(ctx context.Context, id, pID uuid.UUID) (domain.Route, []domain.Waypoint, error) {
 	route, err := r.repo.GetById(ctx, id)
 	if err != nil {
 
@@ -47,7 +130,20 @@ func (r *routesUsecase) GetById(ctx context.Context, id uuid.UUID) (domain.Route
 		return domain.Route{}, nil, domain.ErrInternalServerError
 	}
 
-	waypointIds, err := r.repo.RouteWaypoints(ctx, id, route.RouteKind)
+	if pID != uuid.Nil {
+		if _, err := r.repo.GetPattern(ctx, id, pID); err != nil {
+
+			r.log.Error("get route by id", "error:", err)
+
+			if errors.Is(err, domain.ErrNotFound) {
+				return domain.Route{}, nil, fmt.Errorf("%w: route pattern not found", domain.ErrNotFound)
+			}
+
+			return domain.Route{}, nil, domain.ErrInternalServerError
+		}
+	}
+
+	waypointIds, err := r.repo.RouteWaypoints(ctx, id, pID)
 	if err != nil {
 
 		r.log.Error("get route by id", "error:", err)
@@ -66,10 +162,21 @@ func (r *routesUsecase) Create(ctx context.Context, route domain.Route, waypoint
 
 	route.ID = id
 
-	if err = r.repo.Create(ctx, route, waypointIds); err != nil {
+	if err := r.checkTypes(ctx, &route.VehicleType, &route.RouteType); err != nil {
+		return err
+	}
+
+	err = stageOrApply(ctx, r.drafts, r.audit, domain.DraftRouteCreate, id, draftRouteCreate{Route: route, WaypointIds: waypointIds}, func(ctx context.Context) error {
+		return r.repo.Create(ctx, route, waypointIds)
+	})
+	if err != nil {
 
 		r.log.Error("create route", "error:", err)
 
+		if isDraftError(err) {
+			return err
+		}
+
 		if errors.Is(err, domain.ErrConflict) {
 			return fmt.Errorf("%w: route already exists", domain.ErrConflict)
 		}
@@ -84,11 +191,435 @@ func (r *routesUsecase) Create(ctx context.Context, route domain.Route, waypoint
 	return nil
 }
 
+func (r *routesUsecase) Update(ctx context.Context, id uuid.UUID, update domain.RouteUpdate) (domain.Route, error) {
+	if err := r.checkTypes(ctx, update.VehicleType, update.RouteType); err != nil {
+		return domain.Route{}, err
+	}
+
+	err := stageOrApply(ctx, r.drafts, r.audit, domain.DraftRouteUpdate, id, update, func(ctx context.Context) error {
+		return r.repo.Update(ctx, id, update)
+	})
+	if err != nil {
+
+		r.log.Error("update route", "error:", err)
+
+		if isDraftError(err) {
+			return domain.Route{}, err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Route{}, fmt.Errorf("%w: route not found", domain.ErrNotFound)
+		}
+
+		if errors.Is(err, domain.ErrConflict) {
+			return domain.Route{}, fmt.Errorf("%w: route with such name already exists", domain.ErrConflict)
+		}
+
+		if errors.Is(err, domain.ErrBadRequest) {
+			return domain.Route{}, fmt.Errorf("%w: valid to is before valid from", domain.ErrBadRequest)
+		}
+
+		return domain.Route{}, domain.ErrInternalServerError
+	}
+
+	var route domain.Route
+
+	getRoute := func(ctx context.Context) error {
+		route, err = r.repo.GetById(ctx, id)
+
+		return err
+	}
+
+	// В черновике возвращается маршрут таким, каким он станет после публикации
+	if draftID, ok := domain.DraftFromContext(ctx); ok {
+		err = r.drafts.Preview(ctx, draftID, getRoute)
+	} else {
+		err = getRoute(ctx)
+	}
+
+	if err != nil {
+
+		r.log.Error("update route", "error:", err)
+
+		if isDraftError(err) {
+			return domain.Route{}, err
+		}
+
+		return domain.Route{}, domain.ErrInternalServerError
+	}
+
+	return route, nil
+}
+
+// History возвращает журнал изменений маршрута, включая изменения его списка остановок.
+func (r *routesUsecase) History(ctx context.Context, id uuid.UUID, page domain.Page) (domain.AuditPage, error) {
+	history, err := r.audit.repo.History(ctx, domain.AuditRoute, id, page)
+	if err != nil {
+
+		r.log.Error("route history", "error:", err)
+
+		if errors.Is(err, domain.ErrBadRequest) {
+			return domain.AuditPage{}, fmt.Errorf("%w: invalid cursor", domain.ErrBadRequest)
+		}
+
+		return domain.AuditPage{}, domain.ErrInternalServerError
+	}
+
+	return history, nil
+}
+
+func (r *routesUsecase) ListPatterns(ctx context.Context, id uuid.UUID) ([]domain.RoutePattern, error) {
+	if _, err := r.repo.GetById(ctx, id); err != nil {
+
+		r.log.Error("list route patterns", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil, fmt.Errorf("%w: route not found", domain.ErrNotFound)
+		}
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	patterns, err := r.repo.Patterns(ctx, id)
+	if err != nil {
+
+		r.log.Error("list route patterns", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	return patterns, nil
+}
+
+func (r *routesUsecase) CreatePattern(ctx context.Context, pattern domain.RoutePattern, waypointIds []uuid.UUID) (domain.RoutePattern, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return domain.RoutePattern{}, err
+	}
+
+	pattern.ID = id
+
+	err = stageOrApply(ctx, r.drafts, r.audit, domain.DraftPatternCreate, pattern.RouteID, draftPattern{Pattern: pattern, WaypointIds: waypointIds}, func(ctx context.Context) error {
+		return r.repo.CreatePattern(ctx, pattern, waypointIds)
+	})
+	if err != nil {
+
+		r.log.Error("create route pattern", "error:", err)
+
+		if isDraftError(err) {
+			return domain.RoutePattern{}, err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.RoutePattern{}, fmt.Errorf("%w: route not found", domain.ErrNotFound)
+		}
+
+		if errors.Is(err, domain.ErrConflict) {
+			return domain.RoutePattern{}, fmt.Errorf("%w: pattern with such name already exists or waypoints repeat", domain.ErrConflict)
+		}
+
+		if errors.Is(err, domain.ErrBadRequest) {
+			return domain.RoutePattern{}, fmt.Errorf("%w: invalid waypoints request", domain.ErrBadRequest)
+		}
+
+		return domain.RoutePattern{}, domain.ErrInternalServerError
+	}
+
+	return r.getPattern(ctx, pattern.RouteID, pattern.ID, "create route pattern")
+}
+
+func (r *routesUsecase) UpdatePattern(ctx context.Context, id, pID uuid.UUID, update domain.RoutePatternUpdate) (domain.RoutePattern, error) {
+	payload := draftPattern{Pattern: domain.RoutePattern{ID: pID, RouteID: id}, Update: update}
+
+	err := stageOrApply(ctx, r.drafts, r.audit, domain.DraftPatternUpdate, id, payload, func(ctx context.Context) error {
+		return r.repo.UpdatePattern(ctx, id, pID, update)
+	})
+	if err != nil {
+
+		r.log.Error("update route pattern", "error:", err)
+
+		if isDraftError(err) {
+			return domain.RoutePattern{}, err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.RoutePattern{}, fmt.Errorf("%w: route pattern not found", domain.ErrNotFound)
+		}
+
+		if errors.Is(err, domain.ErrConflict) {
+			return domain.RoutePattern{}, fmt.Errorf("%w: pattern with such name already exists", domain.ErrConflict)
+		}
+
+		return domain.RoutePattern{}, domain.ErrInternalServerError
+	}
+
+	return r.getPattern(ctx, id, pID, "update route pattern")
+}
+
+func (r *routesUsecase) DeletePattern(ctx context.Context, id, pID uuid.UUID) error {
+	payload := draftPattern{Pattern: domain.RoutePattern{ID: pID, RouteID: id}}
+
+	err := stageOrApply(ctx, r.drafts, r.audit, domain.DraftPatternDelete, id, payload, func(ctx context.Context) error {
+		return r.repo.DeletePattern(ctx, id, pID)
+	})
+	if err != nil {
+
+		r.log.Error("delete route pattern", "error:", err)
+
+		if isDraftError(err) {
+			return err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: route pattern not found", domain.ErrNotFound)
+		}
+
+		if errors.Is(err, domain.ErrConflict) {
+			return fmt.Errorf("%w: default pattern cannot be deleted", domain.ErrConflict)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// getPattern возвращает вариант после изменения op. В черновике вариант возвращается таким, каким он станет после публикации.
+func (r *routesUsecase) getPattern(ctx context.Context, id, pID uuid.UUID, op string) (domain.RoutePattern, error) {
+	var (
+		pattern domain.RoutePattern
+		err     error
+	)
+
+	get := func(ctx context.Context) error {
+		pattern, err = r.repo.GetPattern(ctx, id, pID)
+
+		return err
+	}
+
+	if draftID, ok := domain.DraftFromContext(ctx); ok {
+		err = r.drafts.Preview(ctx, draftID, get)
+	} else {
+		err = get(ctx)
+	}
+
+	if err != nil {
+
+		r.log.Error(op, "error:", err)
+
+		if isDraftError(err) {
+			return domain.RoutePattern{}, err
+		}
+
+		return domain.RoutePattern{}, domain.ErrInternalServerError
+	}
+
+	return pattern, nil
+}
+
+// checkTypes проверяет, что типы транспорта и маршрута есть в справочниках. nil не проверяется.
+func (r *routesUsecase) checkTypes(ctx context.Context, vehicleType, routeType *string) error {
+	checks := []struct {
+		repo domain.TypesRepository
+		code *string
+		what string
+	}{
+		{r.vtRepo, vehicleType, "vehicle type"},
+		{r.rtRepo, routeType, "route type"},
+	}
+
+	for _, check := range checks {
+		if check.code == nil {
+			continue
+		}
+
+		if _, err := check.repo.Get(ctx, *check.code); err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				return fmt.Errorf("%w: unknown %s %q", domain.ErrBadRequest, check.what, *check.code)
+			}
+
+			r.log.Error("check route types", "error:", err)
+
+			return domain.ErrInternalServerError
+		}
+	}
+
+	return nil
+}
+
 func (r *routesUsecase) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := r.repo.Delete(ctx, id); err != nil {
+	err := stageOrApply(ctx, r.drafts, r.audit, domain.DraftRouteDelete, id, nil, func(ctx context.Context) error {
+		return r.repo.Delete(ctx, id)
+	})
+	if err != nil {
 
 		r.log.Error("delete route", "error:", err)
 
+		if isDraftError(err) {
+			return err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: route not found", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// Restore восстанавливает удаленный маршрут. Остановки, удаленные вместе с ним, тоже возвращаются.
+func (r *routesUsecase) Restore(ctx context.Context, id uuid.UUID) (domain.Route, error) {
+	entry := auditEntry{EntityType: domain.AuditRoute, EntityID: id, Action: domain.AuditRouteRestore}
+
+	err := r.audit.track(ctx, entry, func(ctx context.Context) error {
+		return r.repo.Restore(ctx, id)
+	})
+	if err != nil {
+
+		r.log.Error("restore route", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Route{}, fmt.Errorf("%w: deleted route not found", domain.ErrNotFound)
+		}
+
+		if errors.Is(err, domain.ErrConflict) {
+			return domain.Route{}, fmt.Errorf("%w: route with such name already exists", domain.ErrConflict)
+		}
+
+		return domain.Route{}, domain.ErrInternalServerError
+	}
+
+	route, err := r.repo.GetById(ctx, id)
+	if err != nil {
+
+		r.log.Error("restore route", "error:", err)
+
+		return domain.Route{}, domain.ErrInternalServerError
+	}
+
+	return route, nil
+}
+
+// Reverse строит обратное направление маршрута: остановки проходятся с конца,
+// и для каждой подбирается ближайшая остановка напротив в радиусе radius метров.
+// Если dryRun, маршрут не создается, а только возвращается предложенный вариант.
+func (r *routesUsecase) Reverse(ctx context.Context, id uuid.UUID, radius float64, dryRun bool) (domain.ReversedRoute, error) {
+	route, waypoints, err := r.getWithWaypoints(ctx, id, uuid.Nil)
+	if err != nil {
+		return domain.ReversedRoute{}, err
+	}
+
+	oppositeKind, ok := domain.OppositeRouteKind(route.RouteKind)
+	if !ok {
+		return domain.ReversedRoute{}, fmt.Errorf("%w: route kind must be 1 or 2", domain.ErrBadRequest)
+	}
+
+	// Остановки прямого направления находятся на той же стороне дороги, поэтому исключаются из поиска.
+	exclude := make([]uuid.UUID, 0, len(waypoints))
+	for _, wp := range waypoints {
+		exclude = append(exclude, wp.ID)
+	}
+
+	var reversed domain.ReversedRoute
+
+	for i := len(waypoints) - 1; i >= 0; i-- {
+		opposite, err := r.wRepo.GetOpposite(ctx, waypoints[i], radius, exclude)
+		if err != nil {
+			if errors.Is(err, domain.ErrNotFound) {
+				reversed.Unmatched = append(reversed.Unmatched, waypoints[i])
+				continue
+			}
+
+			r.log.Error("reverse route", "error:", err)
+
+			return domain.ReversedRoute{}, domain.ErrInternalServerError
+		}
+
+		exclude = append(exclude, opposite.ID)
+		reversed.Waypoints = append(reversed.Waypoints, opposite)
+	}
+
+	reversed.Route = domain.Route{
+		Name:        route.Name,
+		RouteKind:   oppositeKind,
+		Length:      len(reversed.Waypoints),
+		Price:       route.Price,
+		VehicleType: route.VehicleType,
+		RouteType:   route.RouteType,
+		IsLoop:      route.IsLoop,
+
+		ShortName:   route.ShortName,
+		Color:       route.Color,
+		TextColor:   route.TextColor,
+		Description: route.Description,
+		URL:         route.URL,
+	}
+
+	// Полное название обратного направления - по умолчанию, из его остановок.
+	reversed.Route.LongName = domain.DefaultRouteLongName(reversed.Waypoints)
+
+	r.log.Debug("reverse route", "matched:", len(reversed.Waypoints), "unmatched:", len(reversed.Unmatched))
+
+	if dryRun {
+		return reversed, nil
+	}
+
+	if len(reversed.Waypoints) == 0 {
+		return domain.ReversedRoute{}, fmt.Errorf("%w: no opposite waypoints found", domain.ErrNotFound)
+	}
+
+	reversedId, err := uuid.NewUUID()
+	if err != nil {
+		return domain.ReversedRoute{}, err
+	}
+
+	reversed.Route.ID = reversedId
+
+	waypointIds := make([]uuid.UUID, 0, len(reversed.Waypoints))
+	for _, wp := range reversed.Waypoints {
+		waypointIds = append(waypointIds, wp.ID)
+	}
+
+	// Название по умолчанию не сохраняется, чтобы оно менялось вместе с остановками.
+	created := reversed.Route
+	created.LongName = ""
+
+	err = r.audit.track(ctx, auditEntryOf(domain.DraftRouteCreate, created.ID, domain.WaypointRoute{}), func(ctx context.Context) error {
+		return r.repo.Create(ctx, created, waypointIds)
+	})
+	if err != nil {
+
+		r.log.Error("reverse route", "error:", err)
+
+		if errors.Is(err, domain.ErrConflict) {
+			return domain.ReversedRoute{}, fmt.Errorf("%w: reverse route already exists", domain.ErrConflict)
+		}
+
+		return domain.ReversedRoute{}, domain.ErrInternalServerError
+	}
+
+	return reversed, nil
+}
+
+func (r *routesUsecase) ListTranslations(ctx context.Context, id uuid.UUID) ([]domain.Translation, error) {
+	translations, err := r.repo.ListTranslations(ctx, id)
+	if err != nil {
+
+		r.log.Error("list route translations", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	return translations, nil
+}
+
+func (r *routesUsecase) SetTranslation(ctx context.Context, id uuid.UUID, translation domain.Translation) error {
+	if err := r.repo.SetTranslation(ctx, id, translation); err != nil {
+
+		r.log.Error("set route translation", "error:", err)
+
 		if errors.Is(err, domain.ErrNotFound) {
 			return fmt.Errorf("%w: route not found", domain.ErrNotFound)
 		}
@@ -98,3 +629,18 @@ func (r *routesUsecase) Delete(ctx context.Context, id uuid.UUID) error {
 
 	return nil
 }
+
+func (r *routesUsecase) DeleteTranslation(ctx context.Context, id uuid.UUID, lang string) error {
+	if err := r.repo.DeleteTranslation(ctx, id, lang); err != nil {
+
+		r.log.Error("delete route translation", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: translation not found", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
@@ -0,0 +1,266 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+	"github.com/google/uuid"
+)
+
+type stationsUsecase struct {
+	repo  domain.StationsRepository
+	wRepo domain.WaypointsRepository
+
+	log logger.Logger
+}
+
+func NewStationsUsecase(repo domain.StationsRepository, wRepo domain.WaypointsRepository, log logger.Logger) domain.StationsUsecase {
+	return &stationsUsecase{
+		repo:  repo,
+		wRepo: wRepo,
+		log:   log,
+	}
+}
+
+func (s *stationsUsecase) List(ctx context.Context) ([]domain.Station, error) {
+	stations, err := s.repo.List(ctx)
+	if err != nil {
+
+		s.log.Error("list stations", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	return stations, nil
+}
+
+func (s *stationsUsecase) GetById(ctx context.Context, id uuid.UUID) (domain.Station, []domain.Waypoint, error) {
+	station, err := s.repo.GetById(ctx, id)
+	if err != nil {
+
+		s.log.Error("get station", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Station{}, nil, fmt.Errorf("%w: station not found", domain.ErrNotFound)
+		}
+
+		return domain.Station{}, nil, domain.ErrInternalServerError
+	}
+
+	members, err := s.repo.Members(ctx, id)
+	if err != nil {
+
+		s.log.Error("get station", "error:", err)
+
+		return domain.Station{}, nil, domain.ErrInternalServerError
+	}
+
+	localizeWaypointsSlice(ctx, s.wRepo, s.log, members)
+
+	return station, members, nil
+}
+
+func (s *stationsUsecase) Create(ctx context.Context, station domain.Station, waypointIds []uuid.UUID) (domain.Station, error) {
+	id, err := uuid.NewUUID()
+	if err != nil {
+		return domain.Station{}, err
+	}
+
+	station.ID = id
+
+	// Повтор точки в запросе не должен выглядеть как несуществующая точка: репозиторий сверяет количество измененных строк
+	for i := range waypointIds {
+		waypointIds[i] = resolveWaypoint(ctx, s.wRepo, s.log, waypointIds[i])
+	}
+
+	waypointIds = uniqueIds(waypointIds)
+
+	if err := s.repo.Create(ctx, station, waypointIds); err != nil {
+
+		s.log.Error("create station", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Station{}, fmt.Errorf("%w: waypoint not found", domain.ErrNotFound)
+		}
+
+		return domain.Station{}, domain.ErrInternalServerError
+	}
+
+	created, err := s.repo.GetById(ctx, id)
+	if err != nil {
+
+		s.log.Error("create station", "error:", err)
+
+		return domain.Station{}, domain.ErrInternalServerError
+	}
+
+	return created, nil
+}
+
+func (s *stationsUsecase) Update(ctx context.Context, station domain.Station) error {
+	if err := s.repo.Update(ctx, station); err != nil {
+
+		s.log.Error("update station", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: station not found", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (s *stationsUsecase) Delete(ctx context.Context, id uuid.UUID) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+
+		s.log.Error("delete station", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: station not found", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (s *stationsUsecase) AddMember(ctx context.Context, id, wID uuid.UUID) error {
+	wID = resolveWaypoint(ctx, s.wRepo, s.log, wID)
+
+	if err := s.repo.AddMember(ctx, id, wID); err != nil {
+
+		s.log.Error("add station member", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: station or waypoint not found", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (s *stationsUsecase) RemoveMember(ctx context.Context, id, wID uuid.UUID) error {
+	wID = resolveWaypoint(ctx, s.wRepo, s.log, wID)
+
+	if err := s.repo.RemoveMember(ctx, id, wID); err != nil {
+
+		s.log.Error("remove station member", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: waypoint is not a member of the station", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// Propose объединяет пары похожих остановок без станции в связные группы.
+// Группа получает самое частое название среди своих остановок.
+func (s *stationsUsecase) Propose(ctx context.Context, params domain.SimilarityParams) ([]domain.StationProposal, error) {
+	params.WithoutStation = true
+
+	pairs, err := s.wRepo.SimilarPairs(ctx, params)
+	if err != nil {
+
+		s.log.Error("propose stations", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	var (
+		waypoints = make(map[uuid.UUID]domain.Waypoint)
+		parent    = make(map[uuid.UUID]uuid.UUID)
+	)
+
+	var find func(id uuid.UUID) uuid.UUID
+	find = func(id uuid.UUID) uuid.UUID {
+		if parent[id] != id {
+			parent[id] = find(parent[id])
+		}
+		return parent[id]
+	}
+
+	for _, pair := range pairs {
+		for _, wp := range []domain.Waypoint{pair.First, pair.Second} {
+			if _, ok := parent[wp.ID]; !ok {
+				parent[wp.ID] = wp.ID
+				waypoints[wp.ID] = wp
+			}
+		}
+
+		parent[find(pair.First.ID)] = find(pair.Second.ID)
+	}
+
+	groups := make(map[uuid.UUID][]domain.Waypoint)
+	for id, wp := range waypoints {
+		root := find(id)
+		groups[root] = append(groups[root], wp)
+	}
+
+	proposals := make([]domain.StationProposal, 0, len(groups))
+	for _, members := range groups {
+		sort.Slice(members, func(i, j int) bool {
+			if members[i].Name != members[j].Name {
+				return members[i].Name < members[j].Name
+			}
+			return members[i].ID.String() < members[j].ID.String()
+		})
+
+		proposals = append(proposals, domain.StationProposal{
+			Name:      mostCommonName(members),
+			Waypoints: members,
+		})
+	}
+
+	sort.Slice(proposals, func(i, j int) bool {
+		return proposals[i].Name < proposals[j].Name
+	})
+
+	return proposals, nil
+}
+
+// mostCommonName возвращает самое частое название, при равенстве - первое по порядку.
+func mostCommonName(waypoints []domain.Waypoint) string {
+	var (
+		counts = make(map[string]int)
+		best   string
+	)
+
+	for _, wp := range waypoints {
+		counts[wp.Name]++
+
+		if counts[wp.Name] > counts[best] {
+			best = wp.Name
+		}
+	}
+
+	return best
+}
+
+// uniqueIds возвращает ids без повторов в исходном порядке.
+func uniqueIds(ids []uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]struct{}, len(ids))
+	unique := make([]uuid.UUID, 0, len(ids))
+
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+
+	return unique
+}
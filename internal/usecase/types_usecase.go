@@ -0,0 +1,102 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+)
+
+type typesUsecase struct {
+	repo domain.TypesRepository
+	what string // Название записи справочника в сообщениях об ошибках, например "vehicle type"
+
+	log logger.Logger
+}
+
+func NewTypesUsecase(repo domain.TypesRepository, what string, log logger.Logger) domain.TypesUsecase {
+	return &typesUsecase{
+		repo: repo,
+		what: what,
+		log:  log,
+	}
+}
+
+func (t *typesUsecase) List(ctx context.Context) ([]domain.TypeEntry, error) {
+	entries, err := t.repo.List(ctx)
+	if err != nil {
+
+		t.log.Error("list "+t.what+"s", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	return entries, nil
+}
+
+func (t *typesUsecase) Get(ctx context.Context, code string) (domain.TypeEntry, error) {
+	entry, err := t.repo.Get(ctx, code)
+	if err != nil {
+
+		t.log.Error("get "+t.what, "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.TypeEntry{}, fmt.Errorf("%w: %s not found", domain.ErrNotFound, t.what)
+		}
+
+		return domain.TypeEntry{}, domain.ErrInternalServerError
+	}
+
+	return entry, nil
+}
+
+func (t *typesUsecase) Create(ctx context.Context, entry domain.TypeEntry) error {
+	if err := t.repo.Create(ctx, entry); err != nil {
+
+		t.log.Error("create "+t.what, "error:", err)
+
+		if errors.Is(err, domain.ErrConflict) {
+			return fmt.Errorf("%w: %s already exists", domain.ErrConflict, t.what)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (t *typesUsecase) Update(ctx context.Context, entry domain.TypeEntry) (domain.TypeEntry, error) {
+	if err := t.repo.Update(ctx, entry); err != nil {
+
+		t.log.Error("update "+t.what, "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.TypeEntry{}, fmt.Errorf("%w: %s not found", domain.ErrNotFound, t.what)
+		}
+
+		return domain.TypeEntry{}, domain.ErrInternalServerError
+	}
+
+	return entry, nil
+}
+
+func (t *typesUsecase) Delete(ctx context.Context, code string) error {
+	if err := t.repo.Delete(ctx, code); err != nil {
+
+		t.log.Error("delete "+t.what, "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: %s not found", domain.ErrNotFound, t.what)
+		}
+
+		if errors.Is(err, domain.ErrConflict) {
+			return fmt.Errorf("%w: %s is used by routes", domain.ErrConflict, t.what)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
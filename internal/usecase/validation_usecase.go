@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/dzhordano/maps-api/pkg/logger"
+)
+
+type validationUsecase struct {
+	repo domain.ValidationRepository
+
+	log logger.Logger
+}
+
+func NewValidationUsecase(repo domain.ValidationRepository, log logger.Logger) domain.ValidationUsecase {
+	return &validationUsecase{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Validate выполняет все проверки сети и собирает найденные проблемы в один отчет.
+func (v *validationUsecase) Validate(ctx context.Context, params domain.ValidationParams) (domain.ValidationReport, error) {
+	checks := []func(ctx context.Context) ([]domain.NetworkIssue, error){
+		v.repo.LengthMismatches,
+		v.repo.NumberingIssues,
+		func(ctx context.Context) ([]domain.NetworkIssue, error) {
+			return v.repo.StopGaps(ctx, params.MaxStopGap)
+		},
+		v.repo.OrphanStops,
+		v.repo.MissingDirections,
+	}
+
+	var report domain.ValidationReport
+
+	for _, check := range checks {
+		issues, err := check(ctx)
+		if err != nil {
+
+			v.log.Error("validate network", "error:", err)
+
+			return domain.ValidationReport{}, domain.ErrInternalServerError
+		}
+
+		report.Issues = append(report.Issues, issues...)
+	}
+
+	for _, issue := range report.Issues {
+		switch issue.Severity {
+		case domain.SeverityError:
+			report.Errors++
+		case domain.SeverityWarning:
+			report.Warns++
+		}
+	}
+
+	return report, nil
+}
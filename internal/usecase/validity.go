@@ -0,0 +1,18 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+)
+
+// withValidAtOrToday задает сегодняшнюю дату, если дата не задана запросом:
+// поиск маршрутов не должен предлагать закрытые или еще не открытые остановки и маршруты.
+func withValidAtOrToday(ctx context.Context) context.Context {
+	if _, ok := domain.ValidAtFromContext(ctx); ok {
+		return ctx
+	}
+
+	return domain.WithValidAt(ctx, time.Now())
+}
@@ -13,15 +13,22 @@ import (
 type waypointsUsecase struct {
 	wRepo domain.WaypointsRepository
 	rRepo domain.RoutesRepository
+	sRepo domain.StationsRepository
+
+	drafts domain.DraftsUsecase
+	audit  auditor
 
 	log logger.Logger
 }
 
-func NewWaypointsUsecase(wRepo domain.WaypointsRepository, rRepo domain.RoutesRepository, log logger.Logger) domain.WaypointsUsecase {
+func NewWaypointsUsecase(wRepo domain.WaypointsRepository, rRepo domain.RoutesRepository, sRepo domain.StationsRepository, aRepo domain.AuditRepository, drafts domain.DraftsUsecase, log logger.Logger) domain.WaypointsUsecase {
 	return &waypointsUsecase{
-		wRepo: wRepo,
-		rRepo: rRepo,
-		log:   log,
+		wRepo:  wRepo,
+		rRepo:  rRepo,
+		sRepo:  sRepo,
+		drafts: drafts,
+		audit:  auditor{repo: aRepo, wRepo: wRepo, rRepo: rRepo},
+		log:    log,
 	}
 }
 
@@ -33,10 +40,17 @@ func (w *waypointsUsecase) Create(ctx context.Context, waypoint domain.Waypoint)
 
 	waypoint.ID = id
 
-	if err := w.wRepo.Create(ctx, waypoint); err != nil {
+	err = stageOrApply(ctx, w.drafts, w.audit, domain.DraftWaypointCreate, id, waypoint, func(ctx context.Context) error {
+		return w.wRepo.Create(ctx, waypoint)
+	})
+	if err != nil {
 
 		w.log.Error("create waypoint", "error:", err)
 
+		if isDraftError(err) {
+			return err
+		}
+
 		if errors.Is(err, domain.ErrConflict) {
 			return fmt.Errorf("%w: waypoint already exists", domain.ErrConflict)
 		}
@@ -47,12 +61,95 @@ func (w *waypointsUsecase) Create(ctx context.Context, waypoint domain.Waypoint)
 	return nil
 }
 
-func (w *waypointsUsecase) List(ctx context.Context, limit, offset uint64) ([]domain.Waypoint, error) {
-	return w.wRepo.List(ctx, limit, offset)
+func (w *waypointsUsecase) CreateMany(ctx context.Context, waypoints []domain.Waypoint, mode domain.BatchMode) ([]domain.BatchResult, error) {
+	results := make([]domain.BatchResult, len(waypoints))
+
+	for i := range waypoints {
+		id, err := uuid.NewUUID()
+		if err != nil {
+			return nil, err
+		}
+
+		waypoints[i].ID = id
+		results[i].ID = id
+	}
+
+	var created []uuid.UUID
+
+	err := w.audit.repo.InTx(ctx, func(ctx context.Context) error {
+		var err error
+		if created, err = w.wRepo.CreateMany(ctx, waypoints, mode); err != nil {
+			return err
+		}
+
+		return w.audit.recordCreated(ctx, domain.AuditWaypoint, domain.DraftWaypointCreate, created)
+	})
+	if err != nil {
+
+		w.log.Error("create many waypoints", "error:", err)
+
+		if errors.Is(err, domain.ErrConflict) {
+			return nil, fmt.Errorf("%w: some of the waypoints already exist", domain.ErrConflict)
+		}
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	createdSet := make(map[uuid.UUID]struct{}, len(created))
+	for _, id := range created {
+		createdSet[id] = struct{}{}
+	}
+
+	for i := range results {
+		if _, ok := createdSet[results[i].ID]; ok {
+			results[i].Created = true
+			continue
+		}
+
+		results[i].ID = uuid.Nil
+		results[i].Error = "waypoint already exists"
+	}
+
+	return results, nil
 }
 
+func (w *waypointsUsecase) List(ctx context.Context, page domain.Page, filter domain.WaypointFilter) (domain.WaypointsPage, error) {
+	waypoints, err := w.wRepo.List(ctx, page, filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrBadRequest) {
+			return domain.WaypointsPage{}, fmt.Errorf("%w: invalid cursor", domain.ErrBadRequest)
+		}
+
+		w.log.Error("list waypoints", "error:", err)
+
+		return domain.WaypointsPage{}, domain.ErrInternalServerError
+	}
+
+	localizeWaypointsSlice(ctx, w.wRepo, w.log, waypoints.Waypoints)
+
+	return waypoints, nil
+}
+
+func (w *waypointsUsecase) ListWithin(ctx context.Context, area domain.Area, filter domain.WaypointFilter) ([]domain.Waypoint, error) {
+	waypoints, err := w.wRepo.ListWithin(ctx, area, filter)
+	if err != nil {
+		if errors.Is(err, domain.ErrBadRequest) {
+			return nil, fmt.Errorf("%w: invalid polygon", domain.ErrBadRequest)
+		}
+
+		w.log.Error("list waypoints within area", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	localizeWaypointsSlice(ctx, w.wRepo, w.log, waypoints)
+
+	return waypoints, nil
+}
+
+// GetById возвращает точку по id. Для id объединенной остановки возвращается оставшаяся остановка.
 func (w *waypointsUsecase) GetById(ctx context.Context, id uuid.UUID) (domain.Waypoint, error) {
-	wp, err := w.wRepo.GetById(ctx, id)
+	wp, err := w.wRepo.GetById(ctx, resolveWaypoint(ctx, w.wRepo, w.log, id))
 	if err != nil {
 
 		w.log.Error("get waypoint by id", "error:", err)
@@ -64,11 +161,29 @@ func (w *waypointsUsecase) GetById(ctx context.Context, id uuid.UUID) (domain.Wa
 		return domain.Waypoint{}, domain.ErrInternalServerError
 	}
 
+	localizeWaypoints(ctx, w.wRepo, w.log, &wp)
+
 	return wp, nil
 }
 
-func (w *waypointsUsecase) GetOfNearest(ctx context.Context, amount int, latitude, longitude float64) ([]domain.Waypoint, error) {
-	wp, err := w.wRepo.GetOfNearest(ctx, amount, latitude, longitude)
+// resolveWaypoint возвращает id оставшейся остановки, если id - псевдоним объединенной остановки, иначе сам id.
+// Через него проходят все запросы с id точки, чтобы id объединенной остановки продолжал работать.
+// Ошибка поиска псевдонима только записывается в лог: запрос выполняется с исходным id.
+func resolveWaypoint(ctx context.Context, wRepo domain.WaypointsRepository, log logger.Logger, id uuid.UUID) uuid.UUID {
+	survivorID, err := wRepo.ResolveAlias(ctx, id)
+	if err != nil {
+		if !errors.Is(err, domain.ErrNotFound) {
+			log.Error("resolve waypoint alias", "error:", err)
+		}
+
+		return id
+	}
+
+	return survivorID
+}
+
+func (w *waypointsUsecase) GetOfNearest(ctx context.Context, search domain.NearestSearch) ([]domain.NearbyWaypoint, error) {
+	wp, err := w.wRepo.GetOfNearest(ctx, search)
 	if err != nil {
 
 		w.log.Error("get of nearest", "error:", err)
@@ -76,14 +191,44 @@ func (w *waypointsUsecase) GetOfNearest(ctx context.Context, amount int, latitud
 		return nil, domain.ErrInternalServerError
 	}
 
+	localizeNearbyWaypoints(ctx, w.wRepo, w.rRepo, w.log, wp)
+
 	return wp, nil
 }
 
+func (w *waypointsUsecase) Search(ctx context.Context, search domain.WaypointSearch) ([]domain.WaypointMatch, error) {
+	matches, err := w.wRepo.Search(ctx, search)
+	if err != nil {
+
+		w.log.Error("search waypoints", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	waypoints := make([]*domain.Waypoint, len(matches))
+	for i := range matches {
+		waypoints[i] = &matches[i].Waypoint
+	}
+
+	localizeWaypoints(ctx, w.wRepo, w.log, waypoints...)
+
+	return matches, nil
+}
+
 func (w *waypointsUsecase) Update(ctx context.Context, waypoint domain.Waypoint) error {
-	if err := w.wRepo.Update(ctx, waypoint); err != nil {
+	waypoint.ID = resolveWaypoint(ctx, w.wRepo, w.log, waypoint.ID)
+
+	err := stageOrApply(ctx, w.drafts, w.audit, domain.DraftWaypointUpdate, waypoint.ID, waypoint, func(ctx context.Context) error {
+		return w.wRepo.Update(ctx, waypoint)
+	})
+	if err != nil {
 
 		w.log.Error("update waypoint", "error:", err)
 
+		if isDraftError(err) {
+			return err
+		}
+
 		if errors.Is(err, domain.ErrNotFound) {
 			return fmt.Errorf("%w: waypoint not found", domain.ErrNotFound)
 		}
@@ -95,10 +240,19 @@ func (w *waypointsUsecase) Update(ctx context.Context, waypoint domain.Waypoint)
 }
 
 func (w *waypointsUsecase) Delete(ctx context.Context, id uuid.UUID) error {
-	if err := w.wRepo.Delete(ctx, id); err != nil {
+	id = resolveWaypoint(ctx, w.wRepo, w.log, id)
+
+	err := stageOrApply(ctx, w.drafts, w.audit, domain.DraftWaypointDelete, id, nil, func(ctx context.Context) error {
+		return w.wRepo.Delete(ctx, id)
+	})
+	if err != nil {
 
 		w.log.Error("delete waypoint", "error:", err)
 
+		if isDraftError(err) {
+			return err
+		}
+
 		if errors.Is(err, domain.ErrNotFound) {
 			return fmt.Errorf("%w: waypoint not found", domain.ErrNotFound)
 		}
@@ -109,7 +263,53 @@ func (w *waypointsUsecase) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
+// Restore восстанавливает удаленную остановку. Места остановки в маршрутах, удаленные вместе с ней, тоже возвращаются.
+func (w *waypointsUsecase) Restore(ctx context.Context, id uuid.UUID) (domain.Waypoint, error) {
+	entry := auditEntry{EntityType: domain.AuditWaypoint, EntityID: id, Action: domain.AuditWaypointRestore}
+
+	err := w.audit.track(ctx, entry, func(ctx context.Context) error {
+		return w.wRepo.Restore(ctx, id)
+	})
+	if err != nil {
+
+		w.log.Error("restore waypoint", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.Waypoint{}, fmt.Errorf("%w: deleted waypoint not found", domain.ErrNotFound)
+		}
+
+		if errors.Is(err, domain.ErrConflict) {
+			return domain.Waypoint{}, fmt.Errorf("%w: waypoint with the same coordinates already exists", domain.ErrConflict)
+		}
+
+		return domain.Waypoint{}, domain.ErrInternalServerError
+	}
+
+	return w.GetById(ctx, id)
+}
+
+// History возвращает журнал изменений остановки, включая изменения маршрутов, затронувшие ее.
+func (w *waypointsUsecase) History(ctx context.Context, id uuid.UUID, page domain.Page) (domain.AuditPage, error) {
+	id = resolveWaypoint(ctx, w.wRepo, w.log, id)
+
+	history, err := w.audit.repo.History(ctx, domain.AuditWaypoint, id, page)
+	if err != nil {
+
+		w.log.Error("waypoint history", "error:", err)
+
+		if errors.Is(err, domain.ErrBadRequest) {
+			return domain.AuditPage{}, fmt.Errorf("%w: invalid cursor", domain.ErrBadRequest)
+		}
+
+		return domain.AuditPage{}, domain.ErrInternalServerError
+	}
+
+	return history, nil
+}
+
 func (w *waypointsUsecase) ListRoutes(ctx context.Context, wID uuid.UUID) ([]domain.WaypointRoute, error) {
+	wID = resolveWaypoint(ctx, w.wRepo, w.log, wID)
+
 	routes, err := w.wRepo.ListRoutes(ctx, wID)
 	if err != nil {
 
@@ -122,11 +322,151 @@ func (w *waypointsUsecase) ListRoutes(ctx context.Context, wID uuid.UUID) ([]dom
 		return nil, domain.ErrInternalServerError
 	}
 
+	localizeWaypointRoutes(ctx, w.rRepo, w.log, routes)
+
 	return routes, nil
 }
 
-func (w *waypointsUsecase) CommonRoutes(ctx context.Context, w1, w2 uuid.UUID) ([]domain.Route, error) {
-	wr1, err := w.wRepo.WaypointRoutes(ctx, w1)
+func (w *waypointsUsecase) AttachRoute(ctx context.Context, wr domain.WaypointRoute) error {
+	wr.WaypointID = resolveWaypoint(ctx, w.wRepo, w.log, wr.WaypointID)
+
+	err := stageOrApply(ctx, w.drafts, w.audit, domain.DraftStopAttach, wr.WaypointID, wr, func(ctx context.Context) error {
+		return w.rRepo.AttachWaypoint(ctx, wr)
+	})
+	if err != nil {
+
+		w.log.Error("attach route", "error:", err)
+
+		if isDraftError(err) {
+			return err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: route, pattern or waypoint not found", domain.ErrNotFound)
+		}
+
+		if errors.Is(err, domain.ErrConflict) {
+			return fmt.Errorf("%w: waypoint is already on route pattern", domain.ErrConflict)
+		}
+
+		if errors.Is(err, domain.ErrBadRequest) {
+			return fmt.Errorf("%w: route kind does not match route", domain.ErrBadRequest)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (w *waypointsUsecase) DetachRoute(ctx context.Context, wID, rID, pID uuid.UUID) error {
+	wID = resolveWaypoint(ctx, w.wRepo, w.log, wID)
+
+	err := stageOrApply(ctx, w.drafts, w.audit, domain.DraftStopDetach, wID, domain.WaypointRoute{RouteID: rID, PatternID: pID, WaypointID: wID}, func(ctx context.Context) error {
+		return w.rRepo.DetachWaypoint(ctx, rID, pID, wID)
+	})
+	if err != nil {
+
+		w.log.Error("detach route", "error:", err)
+
+		if isDraftError(err) {
+			return err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: waypoint is not on route pattern", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (w *waypointsUsecase) MoveRoute(ctx context.Context, wID, rID, pID uuid.UUID, routeNumber int) error {
+	wID = resolveWaypoint(ctx, w.wRepo, w.log, wID)
+
+	err := stageOrApply(ctx, w.drafts, w.audit, domain.DraftStopMove, wID, domain.WaypointRoute{RouteID: rID, PatternID: pID, WaypointID: wID, RouteNumber: routeNumber}, func(ctx context.Context) error {
+		return w.rRepo.MoveWaypoint(ctx, rID, pID, wID, routeNumber)
+	})
+	if err != nil {
+
+		w.log.Error("move route", "error:", err)
+
+		if isDraftError(err) {
+			return err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: waypoint is not on route pattern", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (w *waypointsUsecase) SetStopRules(ctx context.Context, wID, rID, pID uuid.UUID, pickupType, dropOffType string) error {
+	wID = resolveWaypoint(ctx, w.wRepo, w.log, wID)
+
+	err := stageOrApply(ctx, w.drafts, w.audit, domain.DraftStopRules, wID, domain.WaypointRoute{RouteID: rID, PatternID: pID, WaypointID: wID, PickupType: pickupType, DropOffType: dropOffType}, func(ctx context.Context) error {
+		return w.rRepo.SetStopRules(ctx, rID, pID, wID, pickupType, dropOffType)
+	})
+	if err != nil {
+
+		w.log.Error("set stop rules", "error:", err)
+
+		if isDraftError(err) {
+			return err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: waypoint is not on route pattern", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (w *waypointsUsecase) SetStopValidity(ctx context.Context, wID, rID, pID uuid.UUID, validity domain.Validity) error {
+	wID = resolveWaypoint(ctx, w.wRepo, w.log, wID)
+
+	err := stageOrApply(ctx, w.drafts, w.audit, domain.DraftStopValidity, wID, domain.WaypointRoute{RouteID: rID, PatternID: pID, WaypointID: wID, Validity: validity}, func(ctx context.Context) error {
+		return w.rRepo.SetStopValidity(ctx, rID, pID, wID, validity)
+	})
+	if err != nil {
+
+		w.log.Error("set stop validity", "error:", err)
+
+		if isDraftError(err) {
+			return err
+		}
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: waypoint is not on route pattern", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+// CommonRoutes возвращает маршруты, по которым можно доехать от w1 до w2.
+// Если точка входит в станцию, отправление/прибытие возможно с любой остановки станции.
+// Посадка на w1 и высадка на w2 должны быть разрешены (обычные или по требованию).
+// Для каждого маршрута возвращается самый короткий участок с количеством перегонов и остановками.
+// Учитываются только точки, маршруты и остановки в маршрутах, действующие на дату из контекста (по умолчанию сегодня).
+func (w *waypointsUsecase) CommonRoutes(ctx context.Context, w1, w2 uuid.UUID) ([]domain.CommonRoute, error) {
+	w1 = resolveWaypoint(ctx, w.wRepo, w.log, w1)
+	w2 = resolveWaypoint(ctx, w.wRepo, w.log, w2)
+
+	ctx = withValidAtOrToday(ctx)
+
+	ids1, err := w.sRepo.SiblingIds(ctx, w1)
 	if err != nil {
 
 		w.log.Error("common routes", "error:", err)
@@ -138,7 +478,7 @@ func (w *waypointsUsecase) CommonRoutes(ctx context.Context, w1, w2 uuid.UUID) (
 		return nil, domain.ErrInternalServerError
 	}
 
-	wr2, err := w.wRepo.WaypointRoutes(ctx, w2)
+	ids2, err := w.sRepo.SiblingIds(ctx, w2)
 	if err != nil {
 
 		w.log.Error("common routes", "error:", err)
@@ -150,18 +490,68 @@ func (w *waypointsUsecase) CommonRoutes(ctx context.Context, w1, w2 uuid.UUID) (
 		return nil, domain.ErrInternalServerError
 	}
 
+	// Остановки одной станции - одно и то же место, ехать некуда.
+	for _, id := range ids1 {
+		if id == w2 {
+			return nil, nil
+		}
+	}
+
+	wr1, err := w.wRepo.WaypointRoutes(ctx, ids1...)
+	if err != nil {
+
+		w.log.Error("common routes", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	wr2, err := w.wRepo.WaypointRoutes(ctx, ids2...)
+	if err != nil {
+
+		w.log.Error("common routes", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
 	w.log.Debug("common routes", "w1 routes:", wr1, "w2 routes:", wr2)
 
-	var commonRoutes []uuid.UUID
+	type candidate struct {
+		from, to domain.WaypointRoute
+	}
+
+	var (
+		candidates []candidate
+		routeIds   []uuid.UUID
+		seen       = make(map[uuid.UUID]bool)
+	)
 	for _, r := range wr1 {
+		if r.PickupType == domain.StopRuleNone {
+			continue
+		}
+
 		for _, r2 := range wr2 {
-			if r.RouteID == r2.RouteID && r.RouteKind == r2.RouteKind && r.RouteNumber < r2.RouteNumber {
-				commonRoutes = append(commonRoutes, r.RouteID)
+			if r2.DropOffType == domain.StopRuleNone {
+				continue
+			}
+
+			// Обе остановки должны быть в одном варианте маршрута, иначе рейса между ними может не быть.
+			// Порядок остановок проверяется ниже: на кольцевом маршруте можно ехать через конец маршрута.
+			if r.PatternID == r2.PatternID && r.RouteNumber != r2.RouteNumber {
+				candidates = append(candidates, candidate{from: r, to: r2})
+
+				if !seen[r.RouteID] {
+					seen[r.RouteID] = true
+					routeIds = append(routeIds, r.RouteID)
+				}
 			}
 		}
 	}
 
-	routes, err := w.rRepo.GetByIds(ctx, commonRoutes...)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	routes, err := w.rRepo.GetByIds(ctx, routeIds...)
 	if err != nil {
 
 		w.log.Error("common routes", "error:", err)
@@ -169,11 +559,150 @@ func (w *waypointsUsecase) CommonRoutes(ctx context.Context, w1, w2 uuid.UUID) (
 		return nil, domain.ErrInternalServerError
 	}
 
-	return routes, nil
+	localizeRoutes(ctx, w.rRepo, w.log, routes)
+
+	routesById := make(map[uuid.UUID]domain.Route, len(routes))
+	for _, route := range routes {
+		routesById[route.ID] = route
+	}
+
+	var (
+		patternStops = make(map[uuid.UUID][]domain.Waypoint)
+		best         = make(map[uuid.UUID]domain.CommonRoute)
+	)
+	for _, c := range candidates {
+		route, ok := routesById[c.from.RouteID]
+		if !ok {
+			continue
+		}
+
+		stops, ok := patternStops[c.from.PatternID]
+		if !ok {
+			stops, err = w.rRepo.RouteWaypoints(ctx, route.ID, c.from.PatternID)
+			if err != nil {
+
+				w.log.Error("common routes", "error:", err)
+
+				return nil, domain.ErrInternalServerError
+			}
+
+			patternStops[c.from.PatternID] = stops
+		}
+
+		segment := routeSegment(stops, c.from.WaypointID, c.to.WaypointID, route.IsLoop)
+		if segment == nil {
+			continue
+		}
+
+		// Из нескольких вариантов и остановок станции выбирается самый короткий участок.
+		if prev, ok := best[route.ID]; ok && prev.Stops <= len(segment)-1 {
+			continue
+		}
+
+		best[route.ID] = domain.CommonRoute{
+			Route:     route,
+			PatternID: c.from.PatternID,
+			Stops:     len(segment) - 1,
+			Segment:   segment,
+		}
+	}
+
+	var commonRoutes []domain.CommonRoute
+	for _, route := range routes {
+		if common, ok := best[route.ID]; ok {
+			commonRoutes = append(commonRoutes, common)
+		}
+	}
+
+	var segmentStops []*domain.Waypoint
+	for i := range commonRoutes {
+		for j := range commonRoutes[i].Segment {
+			segmentStops = append(segmentStops, &commonRoutes[i].Segment[j])
+		}
+	}
+
+	localizeWaypoints(ctx, w.wRepo, w.log, segmentStops...)
+
+	return commonRoutes, nil
+}
+
+// routeSegment возвращает остановки варианта маршрута от точки from до точки to включительно.
+// На кольцевом маршруте участок может проходить через конец маршрута к его началу.
+// Возвращает nil, если доехать от from до to нельзя.
+func routeSegment(stops []domain.Waypoint, from, to uuid.UUID, loop bool) []domain.Waypoint {
+	fromIdx, toIdx := -1, -1
+	for i, stop := range stops {
+		switch stop.ID {
+		case from:
+			fromIdx = i
+		case to:
+			toIdx = i
+		}
+	}
+
+	if fromIdx < 0 || toIdx < 0 || fromIdx == toIdx || (fromIdx > toIdx && !loop) {
+		return nil
+	}
+
+	n := len(stops)
+	hops := (toIdx - fromIdx + n) % n
+
+	segment := make([]domain.Waypoint, 0, hops+1)
+	for i := 0; i <= hops; i++ {
+		segment = append(segment, stops[(fromIdx+i)%n])
+	}
+
+	return segment
+}
+
+func (w *waypointsUsecase) FindDuplicates(ctx context.Context, params domain.SimilarityParams) ([]domain.WaypointPair, error) {
+	params.WithoutStation = false
+
+	pairs, err := w.wRepo.SimilarPairs(ctx, params)
+	if err != nil {
+
+		w.log.Error("find duplicate waypoints", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	return pairs, nil
+}
+
+func (w *waypointsUsecase) Merge(ctx context.Context, survivorID, mergedID uuid.UUID) error {
+	survivorID = resolveWaypoint(ctx, w.wRepo, w.log, survivorID)
+
+	if survivorID == mergedID {
+		return fmt.Errorf("%w: cannot merge waypoint with itself", domain.ErrBadRequest)
+	}
+
+	// Объединение записывается в журнал обеих остановок: у объединяемой снимок после - null
+	survivor := auditEntry{EntityType: domain.AuditWaypoint, EntityID: survivorID, Action: domain.AuditWaypointMerge}
+	merged := auditEntry{EntityType: domain.AuditWaypoint, EntityID: mergedID, Action: domain.AuditWaypointMerge}
+
+	err := w.audit.track(ctx, survivor, func(ctx context.Context) error {
+		return w.audit.track(ctx, merged, func(ctx context.Context) error {
+			return w.wRepo.Merge(ctx, survivorID, mergedID)
+		})
+	})
+	if err != nil {
+
+		w.log.Error("merge waypoints", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: waypoint not found", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
 }
 
 func (w *waypointsUsecase) CollectRoutes(ctx context.Context, waypointsAmount int, lat1, lon1, lat2, lon2 float64) ([]domain.CommonRoutes, error) {
-	ws1, err := w.wRepo.GetOfNearest(ctx, waypointsAmount, lat1, lon1)
+	ctx = withValidAtOrToday(ctx)
+
+	ws1, err := w.wRepo.GetOfNearest(ctx, domain.NearestSearch{Latitude: lat1, Longitude: lon1, Amount: waypointsAmount})
 	if err != nil {
 
 		w.log.Error("collect routes", "error:", err)
@@ -181,7 +710,7 @@ func (w *waypointsUsecase) CollectRoutes(ctx context.Context, waypointsAmount in
 		return nil, domain.ErrInternalServerError
 	}
 
-	ws2, err := w.wRepo.GetOfNearest(ctx, waypointsAmount, lat2, lon2)
+	ws2, err := w.wRepo.GetOfNearest(ctx, domain.NearestSearch{Latitude: lat2, Longitude: lon2, Amount: waypointsAmount})
 	if err != nil {
 
 		w.log.Error("collect routes", "error:", err)
@@ -203,6 +732,7 @@ func (w *waypointsUsecase) CollectRoutes(ctx context.Context, waypointsAmount in
 			}
 
 			if len(routes) > 0 {
+				localizeWaypoints(ctx, w.wRepo, w.log, &ws1.Waypoint, &ws2.Waypoint)
 
 				// commonRoutes = append(commonRoutes, domain.CommonRoutes{
 				// 	From:   ws1,
@@ -212,8 +742,8 @@ func (w *waypointsUsecase) CollectRoutes(ctx context.Context, waypointsAmount in
 
 				return append(
 					[]domain.CommonRoutes{}, domain.CommonRoutes{
-						From:   ws1,
-						To:     ws2,
+						From:   ws1.Waypoint,
+						To:     ws2.Waypoint,
 						Routes: routes,
 					}), nil
 			}
@@ -222,3 +752,51 @@ func (w *waypointsUsecase) CollectRoutes(ctx context.Context, waypointsAmount in
 
 	return nil, domain.ErrNotFound
 }
+
+func (w *waypointsUsecase) ListTranslations(ctx context.Context, id uuid.UUID) ([]domain.Translation, error) {
+	id = resolveWaypoint(ctx, w.wRepo, w.log, id)
+
+	translations, err := w.wRepo.ListTranslations(ctx, id)
+	if err != nil {
+
+		w.log.Error("list waypoint translations", "error:", err)
+
+		return nil, domain.ErrInternalServerError
+	}
+
+	return translations, nil
+}
+
+func (w *waypointsUsecase) SetTranslation(ctx context.Context, id uuid.UUID, translation domain.Translation) error {
+	id = resolveWaypoint(ctx, w.wRepo, w.log, id)
+
+	if err := w.wRepo.SetTranslation(ctx, id, translation); err != nil {
+
+		w.log.Error("set waypoint translation", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: waypoint not found", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
+
+func (w *waypointsUsecase) DeleteTranslation(ctx context.Context, id uuid.UUID, lang string) error {
+	id = resolveWaypoint(ctx, w.wRepo, w.log, id)
+
+	if err := w.wRepo.DeleteTranslation(ctx, id, lang); err != nil {
+
+		w.log.Error("delete waypoint translation", "error:", err)
+
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: translation not found", domain.ErrNotFound)
+		}
+
+		return domain.ErrInternalServerError
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/dzhordano/maps-api/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestRouteSegment(t *testing.T) {
+	stops := make([]domain.Waypoint, 5)
+	for i := range stops {
+		stops[i] = domain.Waypoint{ID: uuid.New()}
+	}
+
+	id := func(i int) uuid.UUID { return stops[i].ID }
+
+	tests := []struct {
+		name     string
+		from, to uuid.UUID
+		loop     bool
+		want     []int // Индексы остановок отрезка, nil - отрезка нет
+	}{
+		{name: "forward", from: id(1), to: id(3), want: []int{1, 2, 3}},
+		{name: "forward on loop", from: id(1), to: id(3), loop: true, want: []int{1, 2, 3}},
+		{name: "whole route", from: id(0), to: id(4), want: []int{0, 1, 2, 3, 4}},
+		{name: "backward on non-loop", from: id(3), to: id(1), want: nil},
+		{name: "backward on loop wraps", from: id(3), to: id(1), loop: true, want: []int{3, 4, 0, 1}},
+		{name: "last to first on loop", from: id(4), to: id(0), loop: true, want: []int{4, 0}},
+		{name: "same stop", from: id(2), to: id(2), loop: true, want: nil},
+		{name: "missing from", from: uuid.New(), to: id(2), want: nil},
+		{name: "missing to", from: id(2), to: uuid.New(), loop: true, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := routeSegment(stops, tt.from, tt.to, tt.loop)
+
+			if tt.want == nil {
+				if got != nil {
+					t.Fatalf("routeSegment() = %d stops, want nil", len(got))
+				}
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("routeSegment() = %d stops, want %d", len(got), len(tt.want))
+			}
+
+			for i, idx := range tt.want {
+				if got[i].ID != stops[idx].ID {
+					t.Errorf("stop %d = %s, want stop %d (%s)", i, got[i].ID, idx, stops[idx].ID)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Транслитерация кириллицы в латиницу, совпадает с i18n.Transliterate для строк в нижнем регистре.
+CREATE OR REPLACE FUNCTION transliterate_ru(src TEXT)
+RETURNS TEXT AS $$
+  SELECT translate(
+    replace(replace(replace(replace(replace(replace(replace(replace(replace(lower(src),
+      'щ', 'shch'), 'ё', 'yo'), 'ж', 'zh'), 'х', 'kh'), 'ц', 'ts'), 'ч', 'ch'), 'ш', 'sh'), 'ю', 'yu'), 'я', 'ya'),
+    'абвгдезийклмнопрстуфыэъь',
+    'abvgdeziyklmnoprstufye'
+  );
+$$ LANGUAGE sql IMMUTABLE STRICT;
+
+-- Название остановки латиницей для поиска по транслитерации
+ALTER TABLE waypoints ADD COLUMN name_latin TEXT GENERATED ALWAYS AS (transliterate_ru(name)) STORED;
+
+CREATE INDEX idx_waypoints_name_trgm ON waypoints USING GIN (lower(name) gin_trgm_ops);
+CREATE INDEX idx_waypoints_name_latin_trgm ON waypoints USING GIN (name_latin gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+DROP INDEX IF EXISTS idx_waypoints_name_latin_trgm;
+DROP INDEX IF EXISTS idx_waypoints_name_trgm;
+ALTER TABLE waypoints DROP COLUMN IF EXISTS name_latin;
+DROP FUNCTION IF EXISTS transliterate_ru(TEXT);
+-- +goose StatementEnd
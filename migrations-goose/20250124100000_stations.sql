@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Станции: группы остановок одного места (разные направления и платформы)
+CREATE TABLE IF NOT EXISTS stations (
+  id UUID PRIMARY KEY,
+  name VARCHAR(255) NOT NULL
+);
+
+ALTER TABLE waypoints ADD COLUMN station_id UUID REFERENCES stations(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_waypoints_station_id ON waypoints(station_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+DROP INDEX IF EXISTS idx_waypoints_station_id;
+ALTER TABLE waypoints DROP COLUMN IF EXISTS station_id;
+DROP TABLE IF EXISTS stations;
+-- +goose StatementEnd
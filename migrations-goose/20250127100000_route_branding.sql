@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Оформление маршрута для отображения на карте и в расписаниях
+ALTER TABLE routes
+  ADD COLUMN IF NOT EXISTS short_name VARCHAR(32) NOT NULL DEFAULT '', -- Короткое название ("44"), по умолчанию name
+  ADD COLUMN IF NOT EXISTS long_name VARCHAR(255) NOT NULL DEFAULT '', -- Полное название, по умолчанию "первая – последняя остановка"
+  ADD COLUMN IF NOT EXISTS color VARCHAR(7) NOT NULL DEFAULT '', -- Цвет фона в формате #RRGGBB
+  ADD COLUMN IF NOT EXISTS text_color VARCHAR(7) NOT NULL DEFAULT '', -- Цвет текста в формате #RRGGBB
+  ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS url VARCHAR(2048) NOT NULL DEFAULT '';
+
+-- Полное название направления маршрута по умолчанию: первая и последняя остановки.
+CREATE OR REPLACE FUNCTION route_default_long_name(rid UUID) RETURNS TEXT AS $$
+  SELECT (array_agg(w.name ORDER BY wr.route_number))[1] || ' – ' || (array_agg(w.name ORDER BY wr.route_number DESC))[1]
+  FROM waypoint_routes wr
+  JOIN waypoints w ON w.id = wr.waypoint_id
+  WHERE wr.route_id = rid;
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+DROP FUNCTION IF EXISTS route_default_long_name(UUID);
+
+ALTER TABLE routes
+  DROP COLUMN IF EXISTS short_name,
+  DROP COLUMN IF EXISTS long_name,
+  DROP COLUMN IF EXISTS color,
+  DROP COLUMN IF EXISTS text_color,
+  DROP COLUMN IF EXISTS description,
+  DROP COLUMN IF EXISTS url;
+-- +goose StatementEnd
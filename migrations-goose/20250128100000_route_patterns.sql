@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Варианты трассы направления маршрута (укороченные рейсы, экспрессы, вечерние рейсы и т.д.)
+CREATE TABLE IF NOT EXISTS route_patterns (
+  id UUID PRIMARY KEY,
+  route_id UUID NOT NULL REFERENCES routes(id) ON DELETE CASCADE,
+  name VARCHAR(64) NOT NULL,
+  is_default BOOLEAN NOT NULL DEFAULT FALSE, -- Основной вариант: по нему считается routes.length
+  UNIQUE(route_id, name)
+);
+
+-- У направления ровно один основной вариант
+CREATE UNIQUE INDEX idx_route_patterns_default ON route_patterns(route_id) WHERE is_default;
+
+INSERT INTO route_patterns (id, route_id, name, is_default)
+SELECT gen_random_uuid(), id, 'default', TRUE FROM routes;
+
+ALTER TABLE waypoint_routes ADD COLUMN IF NOT EXISTS pattern_id UUID REFERENCES route_patterns(id) ON DELETE CASCADE;
+
+UPDATE waypoint_routes wr SET pattern_id = p.id
+FROM route_patterns p
+WHERE p.route_id = wr.route_id;
+
+-- Одна остановка может входить в несколько вариантов одного маршрута
+ALTER TABLE waypoint_routes
+  ALTER COLUMN pattern_id SET NOT NULL,
+  DROP CONSTRAINT IF EXISTS waypoint_routes_pkey,
+  ADD PRIMARY KEY (pattern_id, waypoint_id);
+
+CREATE INDEX idx_waypoint_routes_route_id ON waypoint_routes(route_id);
+CREATE INDEX idx_waypoint_routes_waypoint_id ON waypoint_routes(waypoint_id);
+
+-- Полное название по умолчанию строится по основному варианту
+CREATE OR REPLACE FUNCTION route_default_long_name(rid UUID) RETURNS TEXT AS $$
+  SELECT (array_agg(w.name ORDER BY wr.route_number))[1] || ' – ' || (array_agg(w.name ORDER BY wr.route_number DESC))[1]
+  FROM waypoint_routes wr
+  JOIN route_patterns p ON p.id = wr.pattern_id AND p.is_default
+  JOIN waypoints w ON w.id = wr.waypoint_id
+  WHERE wr.route_id = rid;
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+CREATE OR REPLACE FUNCTION route_default_long_name(rid UUID) RETURNS TEXT AS $$
+  SELECT (array_agg(w.name ORDER BY wr.route_number))[1] || ' – ' || (array_agg(w.name ORDER BY wr.route_number DESC))[1]
+  FROM waypoint_routes wr
+  JOIN waypoints w ON w.id = wr.waypoint_id
+  WHERE wr.route_id = rid;
+$$ LANGUAGE SQL STABLE;
+
+DELETE FROM waypoint_routes wr
+USING route_patterns p
+WHERE p.id = wr.pattern_id AND NOT p.is_default;
+
+DROP INDEX IF EXISTS idx_waypoint_routes_route_id;
+DROP INDEX IF EXISTS idx_waypoint_routes_waypoint_id;
+
+ALTER TABLE waypoint_routes
+  DROP CONSTRAINT IF EXISTS waypoint_routes_pkey,
+  DROP COLUMN IF EXISTS pattern_id,
+  ADD PRIMARY KEY (waypoint_id, route_id);
+
+DROP TABLE IF EXISTS route_patterns;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Кольцевой маршрут: после последней остановки транспорт едет к первой
+ALTER TABLE routes ADD COLUMN is_loop BOOLEAN NOT NULL DEFAULT FALSE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+ALTER TABLE routes DROP COLUMN IF EXISTS is_loop;
+-- +goose StatementEnd
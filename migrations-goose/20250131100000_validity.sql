@@ -0,0 +1,39 @@
+-- +goose Up
+-- +goose StatementBegin
+SELECT 'up SQL query';
+-- Период действия остановок, маршрутов и остановок в маршрутах. NULL - без ограничения, обе границы включаются.
+ALTER TABLE waypoints
+  ADD COLUMN valid_from DATE,
+  ADD COLUMN valid_to DATE,
+  ADD CONSTRAINT waypoints_validity_check CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to);
+
+ALTER TABLE routes
+  ADD COLUMN valid_from DATE,
+  ADD COLUMN valid_to DATE,
+  ADD CONSTRAINT routes_validity_check CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to);
+
+ALTER TABLE waypoint_routes
+  ADD COLUMN valid_from DATE,
+  ADD COLUMN valid_to DATE,
+  ADD CONSTRAINT waypoint_routes_validity_check CHECK (valid_from IS NULL OR valid_to IS NULL OR valid_from <= valid_to);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+SELECT 'down SQL query';
+
+ALTER TABLE waypoints
+  DROP CONSTRAINT IF EXISTS waypoints_validity_check,
+  DROP COLUMN IF EXISTS valid_from,
+  DROP COLUMN IF EXISTS valid_to;
+
+ALTER TABLE routes
+  DROP CONSTRAINT IF EXISTS routes_validity_check,
+  DROP COLUMN IF EXISTS valid_from,
+  DROP COLUMN IF EXISTS valid_to;
+
+ALTER TABLE waypoint_routes
+  DROP CONSTRAINT IF EXISTS waypoint_routes_validity_check,
+  DROP COLUMN IF EXISTS valid_from,
+  DROP COLUMN IF EXISTS valid_to;
+-- +goose StatementEnd
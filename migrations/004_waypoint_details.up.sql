@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Доступность остановки для колясок
+CREATE TYPE WHEELCHAIR_BOARDING_ENUM AS ENUM (
+  'unknown', -- Нет информации
+  'accessible', -- Доступна
+  'not_accessible' -- Недоступна
+);
+
+-- Подробная информация об остановке. NULL означает, что информация неизвестна.
+ALTER TABLE waypoints
+  ADD COLUMN wheelchair_boarding WHEELCHAIR_BOARDING_ENUM NOT NULL DEFAULT 'unknown',
+  ADD COLUMN shelter BOOLEAN, -- Навес
+  ADD COLUMN bench BOOLEAN, -- Скамейка
+  ADD COLUMN lighting BOOLEAN, -- Освещение
+  ADD COLUMN tactile_paving BOOLEAN, -- Тактильная плитка
+  ADD COLUMN platform_code VARCHAR(32) NOT NULL DEFAULT '', -- Код платформы/остановки
+  ADD COLUMN description TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- ALTER TABLE waypoints
+--   DROP COLUMN IF EXISTS wheelchair_boarding,
+--   DROP COLUMN IF EXISTS shelter,
+--   DROP COLUMN IF EXISTS bench,
+--   DROP COLUMN IF EXISTS lighting,
+--   DROP COLUMN IF EXISTS tactile_paving,
+--   DROP COLUMN IF EXISTS platform_code,
+--   DROP COLUMN IF EXISTS description;
+-- DROP TYPE IF EXISTS WHEELCHAIR_BOARDING_ENUM;
+-- +goose StatementEnd
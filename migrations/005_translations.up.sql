@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Переводы названий остановок
+CREATE TABLE IF NOT EXISTS waypoint_translations (
+  waypoint_id UUID REFERENCES waypoints(id) ON DELETE CASCADE,
+  lang VARCHAR(3) NOT NULL, -- Основной подтег языка (en, de, ...)
+  name VARCHAR(255) NOT NULL,
+  PRIMARY KEY(waypoint_id, lang)
+);
+
+-- Переводы названий маршрутов
+CREATE TABLE IF NOT EXISTS route_translations (
+  route_id UUID REFERENCES routes(id) ON DELETE CASCADE,
+  lang VARCHAR(3) NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  PRIMARY KEY(route_id, lang)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- DROP TABLE IF EXISTS route_translations;
+-- DROP TABLE IF EXISTS waypoint_translations;
+-- +goose StatementEnd
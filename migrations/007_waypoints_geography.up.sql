@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Индекс для ST_DWithin по geography (поиск в радиусе в метрах)
+CREATE INDEX idx_waypoints_geog ON waypoints USING GIST ((geom::geography));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- DROP INDEX IF EXISTS idx_waypoints_geog;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Идентификаторы остановок, объединенных с другой остановкой (дубликатов)
+CREATE TABLE IF NOT EXISTS waypoint_aliases (
+  alias_id UUID PRIMARY KEY, -- Идентификатор объединенной (удаленной) остановки
+  waypoint_id UUID NOT NULL REFERENCES waypoints(id) ON DELETE CASCADE, -- Оставшаяся остановка
+  name VARCHAR(255) NOT NULL, -- Название объединенной остановки
+  merged_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_waypoint_aliases_waypoint_id ON waypoint_aliases(waypoint_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- DROP TABLE IF EXISTS waypoint_aliases;
+-- +goose StatementEnd
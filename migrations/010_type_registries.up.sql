@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Справочник типов транспорта (вместо ROUTE_VEHICLE_TYPE_ENUM)
+CREATE TABLE IF NOT EXISTS vehicle_types (
+  code VARCHAR(32) PRIMARY KEY, -- Значение routes.vehicle_type
+  display_name VARCHAR(255) NOT NULL,
+  icon_key VARCHAR(64) NOT NULL DEFAULT '',
+  color VARCHAR(7) NOT NULL DEFAULT '', -- Цвет в формате #RRGGBB
+  default_speed NUMERIC NOT NULL DEFAULT 0, -- Средняя скорость по умолчанию, км/ч
+  gtfs_route_type INTEGER -- Значение route_type в GTFS
+);
+
+INSERT INTO vehicle_types (code, display_name, icon_key, color, default_speed, gtfs_route_type) VALUES
+  ('bus', 'Автобус', 'bus', '#1E88E5', 20, 3),
+  ('minibus', 'Маршрутка', 'minibus', '#FB8C00', 25, 3),
+  ('trolleybus', 'Троллейбус', 'trolleybus', '#43A047', 16, 11),
+  ('train', 'Электричка', 'train', '#8E24AA', 50, 2);
+
+-- Справочник типов маршрутов (вместо ROUTE_TYPE_ENUM)
+CREATE TABLE IF NOT EXISTS route_types (
+  code VARCHAR(32) PRIMARY KEY, -- Значение routes.route_type
+  display_name VARCHAR(255) NOT NULL,
+  icon_key VARCHAR(64) NOT NULL DEFAULT '',
+  color VARCHAR(7) NOT NULL DEFAULT '',
+  default_speed NUMERIC NOT NULL DEFAULT 0,
+  gtfs_route_type INTEGER -- Расширенный route_type в GTFS, если задан, уточняет тип транспорта
+);
+
+INSERT INTO route_types (code, display_name, icon_key, color, default_speed, gtfs_route_type) VALUES
+  ('city', 'Городской', 'city', '#546E7A', 20, NULL),
+  ('intercity', 'Междугородний', 'intercity', '#6D4C41', 60, NULL);
+
+ALTER TABLE routes
+  ALTER COLUMN vehicle_type TYPE VARCHAR(32) USING vehicle_type::TEXT,
+  ALTER COLUMN route_type TYPE VARCHAR(32) USING route_type::TEXT,
+  ADD CONSTRAINT routes_vehicle_type_fkey FOREIGN KEY (vehicle_type) REFERENCES vehicle_types(code) ON UPDATE CASCADE,
+  ADD CONSTRAINT routes_route_type_fkey FOREIGN KEY (route_type) REFERENCES route_types(code) ON UPDATE CASCADE;
+
+DROP TYPE IF EXISTS ROUTE_VEHICLE_TYPE_ENUM;
+DROP TYPE IF EXISTS ROUTE_TYPE_ENUM;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- CREATE TYPE ROUTE_VEHICLE_TYPE_ENUM AS ENUM ('bus', 'minibus', 'trolleybus', 'train');
+-- CREATE TYPE ROUTE_TYPE_ENUM AS ENUM ('city', 'intercity');
+
+-- ALTER TABLE routes
+--   DROP CONSTRAINT IF EXISTS routes_vehicle_type_fkey,
+--   DROP CONSTRAINT IF EXISTS routes_route_type_fkey,
+--   ALTER COLUMN vehicle_type TYPE ROUTE_VEHICLE_TYPE_ENUM USING vehicle_type::ROUTE_VEHICLE_TYPE_ENUM,
+--   ALTER COLUMN route_type TYPE ROUTE_TYPE_ENUM USING route_type::ROUTE_TYPE_ENUM;
+
+-- DROP TABLE IF EXISTS route_types;
+-- DROP TABLE IF EXISTS vehicle_types;
+-- +goose StatementEnd
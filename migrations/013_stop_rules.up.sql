@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Правила посадки/высадки на остановке варианта маршрута
+CREATE TYPE STOP_RULE_ENUM AS ENUM (
+  'regular', -- Обычная посадка/высадка
+  'none', -- Посадка/высадка невозможна
+  'on_request' -- По требованию
+);
+
+ALTER TABLE waypoint_routes
+  ADD COLUMN pickup_type STOP_RULE_ENUM NOT NULL DEFAULT 'regular',
+  ADD COLUMN drop_off_type STOP_RULE_ENUM NOT NULL DEFAULT 'regular';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- ALTER TABLE waypoint_routes
+--   DROP COLUMN IF EXISTS pickup_type,
+--   DROP COLUMN IF EXISTS drop_off_type;
+-- DROP TYPE IF EXISTS STOP_RULE_ENUM;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Черновики изменений сети: изменения копятся в draft_changes и применяются к сети одной транзакцией при публикации.
+CREATE TYPE DRAFT_STATUS_ENUM AS ENUM ('open', 'published', 'discarded');
+
+CREATE TABLE IF NOT EXISTS drafts (
+  id UUID PRIMARY KEY,
+  name VARCHAR(256) NOT NULL,
+  status DRAFT_STATUS_ENUM NOT NULL DEFAULT 'open',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  published_at TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS draft_changes (
+  draft_id UUID NOT NULL REFERENCES drafts(id) ON DELETE CASCADE,
+  seq INTEGER NOT NULL,
+  op VARCHAR(32) NOT NULL,
+  entity_id UUID NOT NULL,
+  payload JSONB NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (draft_id, seq)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- DROP TABLE IF EXISTS draft_changes;
+-- DROP TABLE IF EXISTS drafts;
+-- DROP TYPE IF EXISTS DRAFT_STATUS_ENUM;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Журнал изменений остановок и маршрутов со снимками до и после изменения.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id BIGSERIAL PRIMARY KEY,
+  entity_type VARCHAR(16) NOT NULL,
+  entity_id UUID NOT NULL,
+  waypoint_id UUID, -- Остановка для изменений списка остановок маршрута
+  action VARCHAR(32) NOT NULL,
+  actor VARCHAR(256) NOT NULL DEFAULT '',
+  request_id VARCHAR(128) NOT NULL DEFAULT '',
+  before JSONB,
+  after JSONB,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id, id DESC);
+CREATE INDEX IF NOT EXISTS audit_log_waypoint_idx ON audit_log (waypoint_id, id DESC) WHERE waypoint_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- DROP TABLE IF EXISTS audit_log;
+-- +goose StatementEnd
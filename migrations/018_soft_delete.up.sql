@@ -0,0 +1,60 @@
+-- +goose Up
+-- +goose StatementBegin
+-- SELECT 'up SQL query';
+-- Мягкое удаление: удаленные остановки и маршруты скрываются, пока их не восстановят или не очистят.
+-- Остановки маршрутов, удаленные вместе с остановкой или маршрутом, получают то же время удаления.
+ALTER TABLE waypoints ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE routes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+ALTER TABLE waypoint_routes ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+
+-- Удаленные записи не должны мешать создать такие же заново
+ALTER TABLE waypoints DROP CONSTRAINT IF EXISTS waypoints_latitude_longitude_key;
+ALTER TABLE routes DROP CONSTRAINT IF EXISTS routes_name_route_kind_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS waypoints_latitude_longitude_key ON waypoints (latitude, longitude) WHERE deleted_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS routes_name_route_kind_key ON routes (name, route_kind) WHERE deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS waypoints_deleted_at_idx ON waypoints (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS routes_deleted_at_idx ON routes (deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS waypoint_routes_deleted_at_idx ON waypoint_routes (deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Полное название по умолчанию строится по видимым остановкам основного варианта
+CREATE OR REPLACE FUNCTION route_default_long_name(rid UUID) RETURNS TEXT AS $$
+  SELECT (array_agg(w.name ORDER BY wr.route_number))[1] || ' – ' || (array_agg(w.name ORDER BY wr.route_number DESC))[1]
+  FROM waypoint_routes wr
+  JOIN route_patterns p ON p.id = wr.pattern_id AND p.is_default
+  JOIN waypoints w ON w.id = wr.waypoint_id
+  WHERE wr.route_id = rid AND wr.deleted_at IS NULL;
+$$ LANGUAGE SQL STABLE;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+-- SELECT 'down SQL query';
+
+-- CREATE OR REPLACE FUNCTION route_default_long_name(rid UUID) RETURNS TEXT AS $$
+--   SELECT (array_agg(w.name ORDER BY wr.route_number))[1] || ' – ' || (array_agg(w.name ORDER BY wr.route_number DESC))[1]
+--   FROM waypoint_routes wr
+--   JOIN route_patterns p ON p.id = wr.pattern_id AND p.is_default
+--   JOIN waypoints w ON w.id = wr.waypoint_id
+--   WHERE wr.route_id = rid;
+-- $$ LANGUAGE SQL STABLE;
+
+-- DELETE FROM waypoint_routes WHERE deleted_at IS NOT NULL;
+-- DELETE FROM routes WHERE deleted_at IS NOT NULL;
+-- DELETE FROM waypoints WHERE deleted_at IS NOT NULL;
+
+-- DROP INDEX IF EXISTS waypoint_routes_deleted_at_idx;
+-- DROP INDEX IF EXISTS routes_deleted_at_idx;
+-- DROP INDEX IF EXISTS waypoints_deleted_at_idx;
+
+-- DROP INDEX IF EXISTS routes_name_route_kind_key;
+-- DROP INDEX IF EXISTS waypoints_latitude_longitude_key;
+
+-- ALTER TABLE routes ADD CONSTRAINT routes_name_route_kind_key UNIQUE (name, route_kind);
+-- ALTER TABLE waypoints ADD CONSTRAINT waypoints_latitude_longitude_key UNIQUE (latitude, longitude);
+
+-- ALTER TABLE waypoint_routes DROP COLUMN IF EXISTS deleted_at;
+-- ALTER TABLE routes DROP COLUMN IF EXISTS deleted_at;
+-- ALTER TABLE waypoints DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Язык, на котором хранятся исходные названия остановок и маршрутов.
+const SourceLang = "ru"
+
+type langKey struct{}
+
+func WithLang(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, langKey{}, lang)
+}
+
+// LangFromContext возвращает запрошенный язык или SourceLang, если язык не задан.
+func LangFromContext(ctx context.Context) string {
+	if lang, ok := ctx.Value(langKey{}).(string); ok && lang != "" {
+		return lang
+	}
+
+	return SourceLang
+}
+
+// Normalize приводит языковой тег к основному подтегу в нижнем регистре ("en-US" -> "en").
+// Для некорректного тега возвращает пустую строку.
+func Normalize(tag string) string {
+	tag = strings.TrimSpace(tag)
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+
+	tag = strings.ToLower(tag)
+
+	if len(tag) < 2 || len(tag) > 3 {
+		return ""
+	}
+
+	for _, c := range tag {
+		if c < 'a' || c > 'z' {
+			return ""
+		}
+	}
+
+	return tag
+}
+
+// ParseAcceptLanguage возвращает языки из заголовка Accept-Language в порядке убывания веса.
+func ParseAcceptLanguage(header string) []string {
+	type weighted struct {
+		lang string
+		q    float64
+	}
+
+	var langs []weighted
+
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+
+		lang := Normalize(tag)
+		if lang == "" {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if q <= 0 {
+			continue
+		}
+
+		langs = append(langs, weighted{lang: lang, q: q})
+	}
+
+	sort.SliceStable(langs, func(i, j int) bool {
+		return langs[i].q > langs[j].q
+	})
+
+	result := make([]string, 0, len(langs))
+	for _, l := range langs {
+		result = append(result, l.lang)
+	}
+
+	return result
+}
@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"strings"
+	"unicode"
+)
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+}
+
+// Transliterate переводит кириллицу в латиницу ("Улица Айвазовского" -> "Ulitsa Ayvazovskogo").
+// Остальные символы остаются без изменений.
+func Transliterate(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	for _, r := range s {
+		latin, ok := cyrillicToLatin[unicode.ToLower(r)]
+		if !ok {
+			b.WriteRune(r)
+			continue
+		}
+
+		if unicode.IsUpper(r) && latin != "" {
+			b.WriteString(strings.ToUpper(latin[:1]) + latin[1:])
+			continue
+		}
+
+		b.WriteString(latin)
+	}
+
+	return b.String()
+}
+
+// IsLatinLang сообщает, нужна ли для языка транслитерация исходных названий.
+func IsLatinLang(lang string) bool {
+	switch lang {
+	case SourceLang, "uk", "be", "bg", "kk", "ky", "mk", "sr", "tg", "mn", "av", "lez", "dar":
+		return false
+	default:
+		return true
+	}
+}